@@ -4,100 +4,194 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
 	"flag"
+	"github.com/chrisbbe/GoAnalysis/analyzer/gitdiff"
 	"github.com/chrisbbe/GoAnalysis/analyzer/globalvars"
 	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+	"github.com/chrisbbe/GoAnalysis/analyzer/lsp"
+	"github.com/chrisbbe/GoAnalysis/analyzer/report"
+	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 )
 
+// Exit codes of the analyzer.
+const (
+	EXIT_CLEAN          = 0 // No violations at or above the -fail-on severity were found.
+	EXIT_VIOLATIONS     = 1 // Violations at or above the -fail-on severity were found.
+	EXIT_ANALYSIS_ERROR = 2 // A package could not be loaded or analysed, or with -strict, not fully type checked.
+)
+
 var sourceRootDir = flag.String("dir", "", "Absolute path to root directory of Golang source files to be analysed.")
-var jsonOutput = flag.Bool("json", false, "Print result as JSON.")
+var configFile = flag.String("config", "", "Path to JSON configuration file, default is to search for "+linter.CONFIG_FILE_NAME+" from -dir and upwards.")
+var outputFormat = flag.String("format", "text", "Output format: 'text', 'json', 'sarif', 'checkstyle' or 'junit'.")
+var jsonOutput = flag.Bool("json", false, "Print result as JSON, same as -format=json.")
+var htmlOutputDir = flag.String("html", "", "Write a browsable HTML report with source listings, complexity and control-flow graphs to this directory.")
+var metricsFile = flag.String("metrics", "", "Write per-package, per-file and per-function metrics to this file, as CSV if it ends with .csv, otherwise as JSON.")
+var fixSource = flag.Bool("fix", false, "Apply the suggested fixes of the violations, rewriting the source files.")
+var printDiff = flag.Bool("diff", false, "Print the suggested fixes of the violations as unified diff, instead of the violations.")
+var baselineFile = flag.String("baseline", "", "Only report violations not accepted by this baseline file, written by -write-baseline.")
+var writeBaselineFile = flag.String("write-baseline", "", "Write a baseline file accepting all current violations, instead of reporting them.")
+var diffBase = flag.String("diff-base", "", "Only report violations on lines changed relative to this git revision, e.g. 'origin/master...'.")
+var buildTags = flag.String("tags", "", "Comma separated build tags to apply when selecting files, as in 'go build -tags'.")
+var buildGOOS = flag.String("goos", "", "Comma separated target operating systems, analysing each, default is the host's.")
+var buildGOARCH = flag.String("goarch", "", "Comma separated target architectures, analysing each, default is the host's.")
+var buildTests = flag.Bool("tests", false, "Also analyse _test.go files and external test packages.")
+var jobs = flag.Int("j", runtime.GOMAXPROCS(0), "Number of packages to analyse concurrently.")
+var cacheDir = flag.String("cache", defaultCacheDir(), "Directory to cache analysis results in, unchanged packages are not analysed again. Empty disables the cache.")
+var failOn = flag.String("fail-on", "INFO", "Exit with status 1 if violations of this severity or higher are found: 'INFO', 'MINOR', 'MAJOR', 'CRITICAL', 'BLOCKER' or 'NONE'.")
+var strict = flag.Bool("strict", false, "Exit with status 2 if a package could not be fully parsed and type checked.")
 var printHelp = flag.Bool("help", false, "Print this usage help.")
+var printRules = flag.String("rules", "", "Print the registered rules as 'xml' (sonar-go-plugin go-rules.xml) or 'markdown'.")
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "lsp" {
+		serveLSP()
+		return
+	}
 	flag.Parse()
 
 	if flag.NFlag() < 1 {
 		flag.Usage()
 	}
 
+	// Option rules selected.
+	switch *printRules {
+	case "":
+	case "xml":
+		if err := linter.WriteSonarRules(os.Stdout); err != nil {
+			fatal(err)
+		}
+	case "markdown":
+		if err := linter.WriteMarkdownRules(os.Stdout); err != nil {
+			fatal(err)
+		}
+	default:
+		fatalf("Unknown rules format %q, use 'xml' or 'markdown'.", *printRules)
+	}
+
+	if *jsonOutput {
+		*outputFormat = "json"
+	}
+	switch *outputFormat {
+	case "text", "json", "sarif", "checkstyle", "junit":
+	default:
+		fatalf("Unknown output format %q, use 'text', 'json', 'sarif', 'checkstyle' or 'junit'.", *outputFormat)
+	}
+
+	failSeverity, failOnViolations := linter.INFO, !strings.EqualFold(*failOn, "NONE")
+	if failOnViolations {
+		var err error
+		if failSeverity, err = linter.ParseSeverity(*failOn); err != nil {
+			fatalf("Unknown -fail-on severity %q, use 'INFO', 'MINOR', 'MAJOR', 'CRITICAL', 'BLOCKER' or 'NONE'.", *failOn)
+		}
+	}
+
 	// Option dir selected.
 	if len(*sourceRootDir) > 0 {
 		start := time.Now()
 
-		goPackageViolations, err := linter.DetectViolations(*sourceRootDir) // Start the analysis.
+		var config *linter.Config
+		var err error
+		if len(*configFile) > 0 {
+			config, err = linter.LoadConfigFile(*configFile, *sourceRootDir)
+		} else {
+			config, err = linter.LoadConfig(*sourceRootDir)
+		}
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
+		}
+		config.Patterns = flag.Args()
+		config.Builds = buildConfigs(*buildGOOS, *buildGOARCH, *buildTags, *buildTests)
+		config.Jobs = *jobs
+		config.CacheDir = *cacheDir
+		if *fixSource || *printDiff {
+			config.CacheDir = "" // Fixes are type checked with the syntax trees, not kept in the cache.
 		}
+
+		goPackages, err := linter.AnalyzePackages(config) // Start the analysis.
+		if err != nil {
+			fatal(err)
+		}
+		goPackageViolations := linter.WithFindings(goPackages)
 		timeUsed := time.Since(start)
 
-		// Direct output to console as JSON.
-		if *jsonOutput && len(goPackageViolations) > 0 {
-
-			// Aggregate GoFiles to JSON marshalling.
-			violationsMap := make(map[string]*linter.GoFile)
-			for _, goPackage := range goPackageViolations {
-				for _, goFile := range goPackage.Violations {
-					if gf, ok := violationsMap[goFile.FilePath]; ok {
-						gf.Violations = append(gf.Violations, goFile.Violations...)
-					} else {
-						violationsMap[goFile.FilePath] = goFile
-					}
-				}
+		if len(*writeBaselineFile) > 0 {
+			baseline, err := linter.NewBaseline(goPackageViolations)
+			if err != nil {
+				fatal(err)
 			}
-
-			// Convert to list for JSON marshalling.
-			var violations []*linter.GoFile
-			for _, value := range violationsMap {
-				violations = append(violations, value)
+			if err := baseline.WriteFile(*writeBaselineFile); err != nil {
+				fatal(err)
 			}
-
-			json, err := json.MarshalIndent(violations, "", "\t")
+			log.Printf("Baseline with %d fingerprints written to %s.", len(baseline.Violations), *writeBaselineFile)
+			return
+		}
+		if len(*diffBase) > 0 {
+			changedLines, err := gitdiff.Changes(*sourceRootDir, *diffBase)
+			if err != nil {
+				fatal(err)
+			}
+			goPackageViolations = linter.FilterViolations(goPackageViolations, func(goFile *linter.GoFile, violation *linter.Violation) bool {
+				return changedLines.Contains(goFile.FilePath, violation.StartLine, violation.EndLine)
+			})
+		}
+		if len(*baselineFile) > 0 {
+			baseline, err := linter.LoadBaseline(*baselineFile)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
-			if _, err := os.Stdout.Write(json); err != nil {
-				panic(err)
+			if goPackageViolations, err = baseline.Filter(goPackageViolations); err != nil {
+				fatal(err)
 			}
+		}
 
-		} else {
-			log.SetOutput(os.Stdout) // We want to send output to stdout, instead of Stderr.
-			numberOfViolations := 0
-			linesOfCode := 0
-			linesOfComments := 0
-
-			// Nicely print the output to the console.
-			log.Println("-----------------------------------------------------------------------------------------------")
-			for _, goPackage := range goPackageViolations {
-				log.Printf("PACKAGE: %s (%s)", goPackage.Pack.Name, goPackage.Path)
-
-				for _, goFile := range goPackage.Violations {
-					log.Printf("\tViolations in %s :", filepath.Base(goFile.FilePath))
-					for i, vio := range goFile.Violations {
-						log.Printf("\t\t%d) %s\n", i, vio)
-					}
-					linesOfCode += goFile.LinesOfCode
-					linesOfComments += goFile.LinesOfComments
-				}
+		if len(*htmlOutputDir) > 0 {
+			if err := report.WriteHTML(*htmlOutputDir, goPackages, *sourceRootDir); err != nil {
+				fatal(err)
+			}
+		}
+
+		if len(*metricsFile) > 0 {
+			writeMetrics(*metricsFile, goPackages)
+		}
 
-				numberOfViolations += len(goPackage.Violations)
-				log.Println("-----------------------------------------------------------------------------------------------")
+		if *fixSource || *printDiff {
+			fixViolations(goPackageViolations, *fixSource, *printDiff)
+		}
+
+		switch {
+		case *printDiff: // The diff replaces the violation report.
+		case *outputFormat == "json":
+			printJSON(goPackageViolations)
+		case *outputFormat == "sarif":
+			if err := report.WriteSARIF(os.Stdout, goPackageViolations, *sourceRootDir); err != nil {
+				fatal(err)
+			}
+		case *outputFormat == "checkstyle":
+			if err := report.WriteCheckstyle(os.Stdout, goPackageViolations); err != nil {
+				fatal(err)
 			}
-			log.Println("## ANALYSIS SUMMARY ##")
-			log.Printf("Total %d violations found!\n", numberOfViolations)
-			log.Printf("Total number of Go files: %d\n", countGoFiles(*sourceRootDir))
-			log.Printf("Total lines of code (LOC): %d\n", linesOfCode)
-			log.Printf("Total lines of comments: %d\n", linesOfComments)
-			log.Printf("Total time used: %s\n", timeUsed)
-			log.Printf("For rule details: %s\n", globalvars.WIKI_PAGE)
+		case *outputFormat == "junit":
+			if err := report.WriteJUnit(os.Stdout, goPackageViolations); err != nil {
+				fatal(err)
+			}
+		default:
+			printText(goPackageViolations, timeUsed, len(config.Builds) > 0)
 		}
 
+		if failed(goPackages, *strict) {
+			os.Exit(EXIT_ANALYSIS_ERROR)
+		}
+		if failOnViolations && hasViolations(goPackageViolations, failSeverity) {
+			os.Exit(EXIT_VIOLATIONS)
+		}
 	}
 
 	// Print help.
@@ -106,6 +200,195 @@ func main() {
 	}
 }
 
+// serveLSP runs the language server on stdin and stdout until the client exits, exiting with
+// status 1 if the client did not shut the server down first, as the protocol demands.
+func serveLSP() {
+	if err := lsp.NewServer().Serve(os.Stdin, os.Stdout); err != nil {
+		log.Print(err)
+		os.Exit(1)
+	}
+}
+
+// defaultCacheDir returns the default directory of the analysis cache, empty if there is none.
+func defaultCacheDir() string {
+	cacheDir, err := linter.DefaultCacheDir()
+	if err != nil {
+		return ""
+	}
+	return cacheDir
+}
+
+// buildConfigs returns a build configuration for every combination of the comma separated
+// operating systems and architectures, nil if the default build configuration is selected.
+func buildConfigs(goosList string, goarchList string, tags string, tests bool) []linter.BuildConfig {
+	if goosList == "" && goarchList == "" && tags == "" && !tests {
+		return nil
+	}
+	var buildTags []string
+	if tags != "" {
+		buildTags = strings.Split(tags, ",")
+	}
+
+	var builds []linter.BuildConfig
+	for _, goos := range strings.Split(goosList, ",") {
+		for _, goarch := range strings.Split(goarchList, ",") {
+			builds = append(builds, linter.BuildConfig{GOOS: goos, GOARCH: goarch, Tags: buildTags, Tests: tests})
+		}
+	}
+	return builds
+}
+
+// fixViolations applies the suggested fixes of the violations to the source files if write is
+// true, and prints the changes as unified diff if printDiff is true.
+func fixViolations(goPackageViolations []*linter.GoPackage, write bool, printDiff bool) {
+	for _, goFile := range report.GroupByFile(goPackageViolations) {
+		src, err := ioutil.ReadFile(goFile.FilePath)
+		if err != nil {
+			fatal(err)
+		}
+		fixed, err := goFile.ApplyFixes(src)
+		if err != nil {
+			fatalf("Fixing %s: %s", goFile.FilePath, err)
+		}
+		if bytes.Equal(src, fixed) {
+			continue
+		}
+
+		if printDiff {
+			if err := report.WriteUnifiedDiff(os.Stdout, goFile.FilePath, goFile.FilePath, src, fixed); err != nil {
+				fatal(err)
+			}
+		}
+		if write {
+			if err := ioutil.WriteFile(goFile.FilePath, fixed, 0644); err != nil {
+				fatal(err)
+			}
+		}
+	}
+}
+
+// writeMetrics writes the metrics of goPackages to filePath, as CSV if the file name ends
+// with .csv, otherwise as JSON.
+func writeMetrics(filePath string, goPackages []*linter.GoPackage) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		fatal(err)
+	}
+	if strings.EqualFold(filepath.Ext(filePath), ".csv") {
+		err = report.WriteMetricsCSV(file, goPackages)
+	} else {
+		err = report.WriteMetricsJSON(file, goPackages)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// fatal logs v and exits with EXIT_ANALYSIS_ERROR.
+func fatal(v ...interface{}) {
+	log.Print(v...)
+	os.Exit(EXIT_ANALYSIS_ERROR)
+}
+
+// fatalf logs the formatted message and exits with EXIT_ANALYSIS_ERROR.
+func fatalf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+	os.Exit(EXIT_ANALYSIS_ERROR)
+}
+
+// hasViolations returns true if goPackages have violations of severity or higher.
+func hasViolations(goPackages []*linter.GoPackage, severity linter.Severity) bool {
+	for _, goPackage := range goPackages {
+		for _, goFile := range goPackage.Violations {
+			for _, violation := range goFile.Violations {
+				if violation.Severity >= severity {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// failed returns true if any of goPackages could not be loaded or analysed, or with strict,
+// could not be fully parsed and type checked.
+func failed(goPackages []*linter.GoPackage, strict bool) bool {
+	for _, goPackage := range goPackages {
+		for _, diagnostic := range goPackage.Diagnostics {
+			if diagnostic.Failed() || strict && diagnostic.Incomplete() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// printJSON prints the violations and diagnostics aggregated per Go source file as JSON to the console.
+func printJSON(goPackageViolations []*linter.GoPackage) {
+	if err := report.WriteJSON(os.Stdout, goPackageViolations); err != nil {
+		fatal(err)
+	}
+}
+
+// printText nicely prints the violations, diagnostics and an analysis summary to the console, with the
+// build configurations each violation was found under if printBuilds is true.
+func printText(goPackageViolations []*linter.GoPackage, timeUsed time.Duration, printBuilds bool) {
+	log.SetOutput(os.Stdout) // We want to send output to stdout, instead of Stderr.
+	numberOfViolations := 0
+	numberOfDiagnostics := 0
+	linesOfCode := 0
+	linesOfComments := 0
+	blankLines := 0
+
+	log.Println("-----------------------------------------------------------------------------------------------")
+	for _, goPackage := range goPackageViolations {
+		log.Printf("PACKAGE: %s (%s)", goPackage.Pack.Name, goPackage.Path)
+
+		if len(goPackage.Diagnostics) > 0 {
+			log.Printf("\tDiagnostics :")
+			for i, diagnostic := range goPackage.Diagnostics {
+				if printBuilds {
+					log.Printf("\t\t%d) %s [%s]\n", i, diagnostic, strings.Join(diagnostic.Builds, "; "))
+				} else {
+					log.Printf("\t\t%d) %s\n", i, diagnostic)
+				}
+			}
+			numberOfDiagnostics += len(goPackage.Diagnostics)
+		}
+
+		for _, goFile := range goPackage.Violations {
+			log.Printf("\tViolations in %s :", filepath.Base(goFile.FilePath))
+			for i, vio := range goFile.Violations {
+				if printBuilds {
+					log.Printf("\t\t%d) %s [%s]\n", i, vio, strings.Join(vio.Builds, "; "))
+				} else {
+					log.Printf("\t\t%d) %s\n", i, vio)
+				}
+			}
+			linesOfCode += goFile.LinesOfCode
+			linesOfComments += goFile.LinesOfComments
+			blankLines += goFile.BlankLines
+		}
+
+		numberOfViolations += len(goPackage.Violations)
+		log.Println("-----------------------------------------------------------------------------------------------")
+	}
+	log.Println("## ANALYSIS SUMMARY ##")
+	log.Printf("Total %d violations found!\n", numberOfViolations)
+	if numberOfDiagnostics > 0 {
+		log.Printf("Total %d diagnostics, some packages were not analysed completely!\n", numberOfDiagnostics)
+	}
+	log.Printf("Total number of Go files: %d\n", countGoFiles(*sourceRootDir))
+	log.Printf("Total lines of code (LOC): %d\n", linesOfCode)
+	log.Printf("Total lines of comments: %d\n", linesOfComments)
+	log.Printf("Total blank lines: %d\n", blankLines)
+	log.Printf("Total time used: %s\n", timeUsed)
+	log.Printf("For rule details: %s\n", globalvars.WIKI_PAGE)
+}
+
 // getGoFiles searches recursively for .go files in the searchDir path, returning the absolute path to the files.
 func countGoFiles(searchDir string) (counter int) {
 	filepath.Walk(searchDir, func(pat string, file os.FileInfo, err error) error {
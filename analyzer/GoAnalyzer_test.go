@@ -0,0 +1,102 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package main
+
+import (
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+// The test binary runs the analyzer instead of the tests if this environment variable is set.
+const RUN_ANALYZER = "GOANALYZER_TEST_RUN_ANALYZER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(RUN_ANALYZER) != "" {
+		main()
+		os.Exit(EXIT_CLEAN)
+	}
+	os.Exit(m.Run())
+}
+
+// A rule failing on files declaring triggerCheckerPanic, like a checker with a bug.
+func init() {
+	linter.Register(linter.NewChecker(linter.RuleInfo{
+		Rule:        "CHECKER_PANICS",
+		Name:        "Checker panics",
+		Severity:    linter.INFO,
+		Description: "Test rule panicking on files declaring triggerCheckerPanic.",
+	}, func(goFile *linter.GoFile) {
+		goFile.Walk(func(node ast.Node) bool {
+			if funcDecl, ok := node.(*ast.FuncDecl); ok && funcDecl.Name.Name == "triggerCheckerPanic" {
+				panic("checker bug")
+			}
+			return true
+		})
+	}))
+}
+
+// runAnalyzer runs the analyzer with args and returns its exit status.
+func runAnalyzer(t *testing.T, args ...string) int {
+	cmd := exec.Command(os.Args[0], append([]string{"-cache="}, args...)...)
+	cmd.Env = append(os.Environ(), RUN_ANALYZER+"=1")
+	output, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatalf("Failed to run analyzer: %v\n%s", err, output)
+	}
+	return EXIT_CLEAN
+}
+
+// writePackage writes a module with main.go holding source to a new directory and returns it.
+func writePackage(t *testing.T, source string) string {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/exit\n\ngo 1.25\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestExitCodes(t *testing.T) {
+	clean := writePackage(t, "package main\n\nfunc main() {}\n")
+	violating := writePackage(t, "package main\n\nfunc main() {\n\tgoto end\nend:\n}\n")
+	typeError := writePackage(t, "package main\n\nfunc main() {\n\tx = 1\n}\n")
+	parseError := writePackage(t, "package main\n\nfunc main() {\n")
+	panicking := writePackage(t, "package main\n\nfunc main() {}\n\nfunc triggerCheckerPanic() {}\n")
+
+	testCases := []struct {
+		Name     string
+		Args     []string
+		ExitCode int
+	}{
+		{Name: "Clean", Args: []string{"-dir=" + clean}, ExitCode: EXIT_CLEAN},
+		{Name: "Violations", Args: []string{"-dir=" + violating}, ExitCode: EXIT_VIOLATIONS},
+		{Name: "ViolationsFailOnNone", Args: []string{"-dir=" + violating, "-fail-on=NONE"}, ExitCode: EXIT_CLEAN},
+		{Name: "NonexistentPackage", Args: []string{"-dir=" + clean, "./nonexist"}, ExitCode: EXIT_ANALYSIS_ERROR},
+		{Name: "NonexistentPackageFailOnNone", Args: []string{"-dir=" + clean, "-fail-on=NONE", "./nonexist"}, ExitCode: EXIT_ANALYSIS_ERROR},
+		{Name: "CheckerPanics", Args: []string{"-dir=" + panicking, "-fail-on=NONE"}, ExitCode: EXIT_ANALYSIS_ERROR},
+		{Name: "CheckerPanicsStrict", Args: []string{"-dir=" + panicking, "-fail-on=NONE", "-strict"}, ExitCode: EXIT_ANALYSIS_ERROR},
+		{Name: "TypeError", Args: []string{"-dir=" + typeError, "-fail-on=NONE"}, ExitCode: EXIT_CLEAN},
+		{Name: "TypeErrorStrict", Args: []string{"-dir=" + typeError, "-fail-on=NONE", "-strict"}, ExitCode: EXIT_ANALYSIS_ERROR},
+		{Name: "ParseError", Args: []string{"-dir=" + parseError, "-fail-on=NONE"}, ExitCode: EXIT_CLEAN},
+		{Name: "ParseErrorStrict", Args: []string{"-dir=" + parseError, "-fail-on=NONE", "-strict"}, ExitCode: EXIT_ANALYSIS_ERROR},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			if exitCode := runAnalyzer(t, testCase.Args...); exitCode != testCase.ExitCode {
+				t.Errorf("Exit code of %v should be %d, but is %d", testCase.Args, testCase.ExitCode, exitCode)
+			}
+		})
+	}
+}
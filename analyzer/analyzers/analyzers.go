@@ -0,0 +1,120 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+
+// Package analyzers exposes the registered rules as golang.org/x/tools/go/analysis Analyzers,
+// so they can be run by go vet -vettool, singlechecker, multichecker or gopls.
+package analyzers
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/globalvars"
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+	"golang.org/x/tools/go/analysis"
+)
+
+var (
+	analyzersMu sync.Mutex
+	analyzers   = make(map[linter.Rule]*analysis.Analyzer)
+)
+
+// Analyzers returns the Analyzer of every registered rule, in registration order.
+func Analyzers() []*analysis.Analyzer {
+	var all []*analysis.Analyzer
+	for _, checker := range linter.Checkers() {
+		all = append(all, Analyzer(checker.Info().Rule))
+	}
+	return all
+}
+
+// Analyzer returns the Analyzer of the registered rule, named like the rule in lower case,
+// e.g. cyclomatic_complexity. Nil is returned if the rule is not registered.
+func Analyzer(rule linter.Rule) *analysis.Analyzer {
+	info := linter.LookupRule(rule)
+	if info == nil {
+		return nil
+	}
+
+	analyzersMu.Lock()
+	defer analyzersMu.Unlock()
+	if analyzer, ok := analyzers[rule]; ok {
+		return analyzer
+	}
+	analyzer := &analysis.Analyzer{
+		Name: strings.ToLower(rule.String()),
+		Doc:  fmt.Sprintf("%s\n\n%s", info.Name, info.Description),
+		URL:  globalvars.WIKI_PAGE,
+		Run:  func(pass *analysis.Pass) (interface{}, error) { return run(pass, rule) },
+	}
+	analyzers[rule] = analyzer
+	return analyzer
+}
+
+// run checks the files of the package with rule, configured by the configuration file found
+// from the package directory, and reports the violations. UNUSED_SUPPRESSION is checked with
+// all rules, as a suppression is unused only if no rule has violations to suppress. A failing
+// checker is returned as an error, not ending the driver.
+func run(pass *analysis.Pass, rule linter.Rule) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("analysis failed: %v", r)
+		}
+	}()
+	if len(pass.Files) == 0 {
+		return nil, nil
+	}
+	config, err := linter.LoadConfig(filepath.Dir(pass.Fset.File(pass.Files[0].Pos()).Name()))
+	if err != nil {
+		return nil, err
+	}
+	rules := []linter.Rule{rule}
+	if rule == linter.UNUSED_SUPPRESSION {
+		rules = nil
+	}
+
+	var errs []error
+	for _, file := range pass.Files {
+		goFile, err := linter.CheckFile(config, pass.Fset, file, pass.Pkg, pass.Files, pass.TypesInfo, rules...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if goFile == nil {
+			continue
+		}
+		tokenFile := pass.Fset.File(file.Pos())
+		for _, violation := range goFile.Violations {
+			if violation.Type == rule {
+				pass.Report(newDiagnostic(tokenFile, violation, goFile.ValidFixes(violation)))
+			}
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// newDiagnostic returns the diagnostic reporting the violation in tokenFile, suggesting fixes.
+func newDiagnostic(tokenFile *token.File, violation *linter.Violation, fixes []*linter.SuggestedFix) analysis.Diagnostic {
+	diagnostic := analysis.Diagnostic{
+		Pos:      tokenFile.Pos(violation.StartOffset),
+		End:      tokenFile.Pos(violation.EndOffset),
+		Category: violation.Type.String(),
+		Message:  violation.Description,
+	}
+	for _, fix := range fixes {
+		suggestedFix := analysis.SuggestedFix{Message: fix.Message}
+		for _, textEdit := range fix.TextEdits {
+			suggestedFix.TextEdits = append(suggestedFix.TextEdits, analysis.TextEdit{
+				Pos:     tokenFile.Pos(textEdit.StartOffset),
+				End:     tokenFile.Pos(textEdit.EndOffset),
+				NewText: []byte(textEdit.NewText),
+			})
+		}
+		diagnostic.SuggestedFixes = append(diagnostic.SuggestedFixes, suggestedFix)
+	}
+	return diagnostic
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package analyzers_test
+
+import (
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/analyzers"
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzers(t *testing.T) {
+	all := analyzers.Analyzers()
+	if len(all) != len(linter.Checkers()) {
+		t.Fatalf("Number of analyzers should be %d, but are %d!", len(linter.Checkers()), len(all))
+	}
+	if err := analysis.Validate(all); err != nil {
+		t.Fatal(err)
+	}
+	if analyzer := analyzers.Analyzer(linter.CYCLOMATIC_COMPLEXITY); analyzer != all[0] || analyzer.Name != "cyclomatic_complexity" {
+		t.Errorf("Analyzer of %s should be the first, named cyclomatic_complexity!", linter.CYCLOMATIC_COMPLEXITY)
+	}
+}
+
+func TestAnalyzerWithSuggestedFixes(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), analyzers.Analyzer(linter.MAP_ALLOCATED_WITH_NEW), "newmaps")
+}
+
+func TestAnalyzerWithSuppressions(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzers.Analyzer(linter.EMPTY_IF_BODY), "suppressed")
+	analysistest.Run(t, analysistest.TestData(), analyzers.Analyzer(linter.UNUSED_SUPPRESSION), "unused")
+}
@@ -0,0 +1,11 @@
+package newmaps
+
+func newMap() {
+	m := new(map[string]int) // want "Maps must be initialized with make"
+	_ = m
+}
+
+func newMapDereferenced() map[string]int {
+	m := new(map[string]int) // want "Maps must be initialized with make"
+	return *m
+}
@@ -0,0 +1,9 @@
+package suppressed
+
+func empty(x int) {
+	if x > 0 { // @SuppressRule("EMPTY_IF_BODY", reason="Reported elsewhere.")
+	}
+	if x > 1 { // want "If body is empty"
+	}
+	x++ // @SuppressRule("EMPTY_FOR_BODY", reason="Stale, but only reported with all rules.")
+}
@@ -0,0 +1,7 @@
+package unused
+
+func empty(x int) {
+	if x > 0 { // @SuppressRule("EMPTY_IF_BODY", reason="Used.")
+	}
+	x++ // @SuppressRule("EMPTY_FOR_BODY", reason="Stale.") // want "No violations of EMPTY_FOR_BODY are suppressed"
+}
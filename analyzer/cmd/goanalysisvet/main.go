@@ -0,0 +1,21 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+
+// Command goanalysisvet runs the GoAnalysis rules as go/analysis Analyzers, on package patterns
+// or as the vet tool of the go command:
+//
+//	goanalysisvet ./...
+//	go vet -vettool=$(which goanalysisvet) ./...
+//
+// Analyzers are named like the rules in lower case, e.g. -cyclomatic_complexity selects one.
+package main
+
+import (
+	"github.com/chrisbbe/GoAnalysis/analyzer/analyzers"
+	"golang.org/x/tools/go/analysis/multichecker"
+)
+
+func main() {
+	multichecker.Main(analyzers.Analyzers()...)
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+
+// Package gitdiff finds the lines changed relative to a git revision, using the local git binary.
+package gitdiff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LineRange is the range of lines from Start to End, both included.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// ChangedLines maps the absolute path of changed files, without symbolic links, to the ranges of added or modified lines.
+type ChangedLines map[string][]LineRange
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// Changes returns the lines changed in the working tree of the git repository holding
+// dir, relative to the revision rev. Any revision accepted by 'git diff' can be used,
+// like "origin/master..." for the changes since the branch was created.
+func Changes(dir string, rev string) (ChangedLines, error) {
+	repoRoot, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	// The prefixes are set as expected by Parse, overriding diff.noprefix and diff.mnemonicPrefix.
+	diff, err := git(dir, "diff", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "--unified=0", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	return Parse(strings.NewReader(diff), strings.TrimSpace(repoRoot))
+}
+
+// git runs the git command with args in dir, returning the output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// Parse reads the unified diff in r, as written by 'git diff', returning the lines added or
+// modified in the new files. File paths in the diff are relative to repoRoot, and the new
+// files have the default "b/" prefix.
+func Parse(r io.Reader, repoRoot string) (ChangedLines, error) {
+	changedLines := make(ChangedLines)
+	var filePath string
+	oldLines, newLines := 0, 0 // Lines left in the body of the current hunk.
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case oldLines > 0 || newLines > 0:
+			// Hunk body, lines starting with "+++ " are content and not file headers.
+			switch {
+			case strings.HasPrefix(line, "-"):
+				oldLines--
+			case strings.HasPrefix(line, "+"):
+				newLines--
+			case strings.HasPrefix(line, " "):
+				oldLines, newLines = oldLines-1, newLines-1
+			}
+
+		case strings.HasPrefix(line, "+++ "):
+			name := strings.TrimPrefix(line, "+++ ")
+			if strings.HasPrefix(name, `"`) {
+				unquoted, err := strconv.Unquote(name)
+				if err != nil {
+					return nil, fmt.Errorf("invalid file name in diff: %s", name)
+				}
+				name = unquoted
+			}
+			if name == "/dev/null" {
+				filePath = "" // File deleted.
+			} else {
+				filePath = filepath.Join(repoRoot, filepath.FromSlash(strings.TrimPrefix(name, "b/")))
+			}
+
+		case strings.HasPrefix(line, "@@ "):
+			match := hunkHeader.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("invalid hunk header in diff: %s", line)
+			}
+			oldLines, newLines = lineCount(match[1]), lineCount(match[3])
+			start, _ := strconv.Atoi(match[2])
+			if filePath != "" && newLines > 0 { // Hunks only deleting lines have no lines in the new file.
+				changedLines[filePath] = append(changedLines[filePath], LineRange{Start: start, End: start + newLines - 1})
+			}
+		}
+	}
+	return changedLines, scanner.Err()
+}
+
+// lineCount returns the number of lines in a hunk range, which is 1 if the count is left out.
+func lineCount(count string) int {
+	if count == "" {
+		return 1
+	}
+	lines, _ := strconv.Atoi(count)
+	return lines
+}
+
+// Contains returns true if any of the lines from start to end in the file at filePath are changed.
+func (changedLines ChangedLines) Contains(filePath string, start int, end int) bool {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return false
+	}
+	if realPath, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = realPath // Git reports paths in the repository without symbolic links.
+	}
+	for _, lineRange := range changedLines[absPath] {
+		if lineRange.Start <= end && start <= lineRange.End {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package gitdiff_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/gitdiff"
+)
+
+const diff = `diff --git a/main.go b/main.go
+index 3b18e51..a0b1c2d 100644
+--- a/main.go
++++ b/main.go
+@@ -3,0 +4,3 @@ import "log"
++// Added comment.
++// Another one.
++++ not a file header, but content.
+@@ -10 +12 @@ func main() {
+-	log.Print("a")
++	log.Print("b")
+@@ -20,2 +21,0 @@ func main() {
+-	x := 1
+-	y := 2
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+-package main
+-
+-func old() {}
+diff --git "a/dir/with \"quote\".go" "b/dir/with \"quote\".go"
+--- "a/dir/with \"quote\".go"
++++ "b/dir/with \"quote\".go"
+@@ -1 +1,3 @@
+-package dir
++package dir
++
++func f() {}
+`
+
+func TestParse(t *testing.T) {
+	repoRoot, err := filepath.Abs("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changedLines, err := gitdiff.Parse(strings.NewReader(diff), repoRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mainGo := filepath.Join(repoRoot, "main.go")
+	for _, test := range []struct {
+		file       string
+		start, end int
+		changed    bool
+	}{
+		{mainGo, 4, 4, true},
+		{mainGo, 5, 5, true},
+		{mainGo, 6, 6, true},
+		{mainGo, 7, 11, false},
+		{mainGo, 10, 14, true}, // Range overlapping the change on line 12.
+		{mainGo, 21, 21, false},
+		{filepath.Join(repoRoot, "old.go"), 1, 1, false},
+		{filepath.Join(repoRoot, "dir", `with "quote".go`), 3, 3, true},
+		{filepath.Join(repoRoot, "other.go"), 1, 100, false},
+	} {
+		if changed := changedLines.Contains(test.file, test.start, test.end); changed != test.changed {
+			t.Errorf("Lines %d-%d in %s should be changed = %v, not %v!", test.start, test.end, test.file, test.changed, changed)
+		}
+	}
+}
+
+// The file prefixes are the same whatever the git configuration of the repository is.
+func TestChangesWithPrefixConfig(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for _, config := range []string{"diff.noprefix", "diff.mnemonicPrefix"} {
+		t.Run(config, func(t *testing.T) {
+			dir := t.TempDir()
+			git := func(args ...string) {
+				cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+				cmd.Dir = dir
+				if output, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+				}
+			}
+			mainGo := filepath.Join(dir, "b", "main.go") // In a directory named like the prefix.
+			if err := os.Mkdir(filepath.Dir(mainGo), 0755); err != nil {
+				t.Fatal(err)
+			}
+			git("init", "-q")
+			git("config", config, "true")
+			if err := ioutil.WriteFile(mainGo, []byte("package main\n\nfunc main() {\n}\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			git("add", mainGo)
+			git("commit", "-q", "-m", "Add main.go")
+			if err := ioutil.WriteFile(mainGo, []byte("package main\n\nfunc main() {\n\tprintln()\n}\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			changedLines, err := gitdiff.Changes(dir, "HEAD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !changedLines.Contains(mainGo, 4, 4) || changedLines.Contains(mainGo, 1, 3) {
+				t.Errorf("Only line 4 of %s should be changed, but changed lines are %v", mainGo, changedLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const BASELINE_VERSION = 1
+
+// Baseline holds the violations accepted in a code base, so only new violations are reported.
+// Violations are identified by fingerprints that do not change when unrelated lines are moved.
+type Baseline struct {
+	Version    int              `json:"version"`
+	Violations []*BaselineEntry `json:"violations"`
+}
+
+// BaselineEntry holds the fingerprint of accepted violations, and what it was computed from.
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Rule        Rule   `json:"rule"`
+	File        string `json:"file"`               // Path relative to the configuration root, using forward slashes.
+	Function    string `json:"function,omitempty"` // Enclosing function or method, empty if none.
+	Snippet     string `json:"snippet"`            // First line of the offending code, tokens separated by a space.
+	Count       int    `json:"count"`              // Number of violations having the fingerprint.
+}
+
+// NewBaseline returns the baseline accepting all violations in goPackages.
+func NewBaseline(goPackages []*GoPackage) (*Baseline, error) {
+	baseline := &Baseline{Version: BASELINE_VERSION, Violations: []*BaselineEntry{}}
+	entries := make(map[string]*BaselineEntry)
+	var goFiles []*GoFile
+	for _, goPackage := range goPackages {
+		goFiles = append(goFiles, goPackage.Violations...)
+	}
+	sort.Slice(goFiles, func(i, j int) bool { return goFiles[i].FilePath < goFiles[j].FilePath })
+
+	for _, goFile := range goFiles {
+		sortViolations(goFile.Violations)
+		for _, violation := range goFile.Violations {
+			entry, err := goFile.fingerprint(violation)
+			if err != nil {
+				return nil, err
+			}
+			if existing, ok := entries[entry.Fingerprint]; ok {
+				existing.Count++
+			} else {
+				entry.Count = 1
+				entries[entry.Fingerprint] = entry
+				baseline.Violations = append(baseline.Violations, entry)
+			}
+		}
+	}
+	return baseline, nil
+}
+
+// LoadBaseline reads the baseline file written by WriteFile at path.
+func LoadBaseline(path string) (*Baseline, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	baseline := &Baseline{}
+	if err := json.Unmarshal(content, baseline); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if baseline.Version != BASELINE_VERSION {
+		return nil, fmt.Errorf("%s: unsupported baseline version %d, expected %d", path, baseline.Version, BASELINE_VERSION)
+	}
+	return baseline, nil
+}
+
+// WriteFile writes the baseline as JSON to the file at path.
+func (baseline *Baseline) WriteFile(path string) error {
+	content, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// Filter removes the violations accepted by the baseline from goPackages, returning the
+// packages still having violations or diagnostics. If a fingerprint is found more often
+// than accepted, the violations on the first lines are considered accepted.
+func (baseline *Baseline) Filter(goPackages []*GoPackage) ([]*GoPackage, error) {
+	remaining := make(map[string]int)
+	for _, entry := range baseline.Violations {
+		remaining[entry.Fingerprint] += entry.Count
+	}
+
+	var err error
+	goPackages = FilterViolations(goPackages, func(goFile *GoFile, violation *Violation) bool {
+		if err != nil {
+			return true
+		}
+		var entry *BaselineEntry
+		if entry, err = goFile.fingerprint(violation); err != nil {
+			return true
+		}
+		if remaining[entry.Fingerprint] > 0 {
+			remaining[entry.Fingerprint]--
+			return false
+		}
+		return true
+	})
+	return goPackages, err
+}
+
+// FilterViolations keeps the violations in goPackages for which keep returns true, returning
+// the packages still having violations or diagnostics. Files are visited in path order and
+// violations in line order.
+func FilterViolations(goPackages []*GoPackage, keep func(goFile *GoFile, violation *Violation) bool) []*GoPackage {
+	for _, goPackage := range goPackages {
+		var goFiles []*GoFile
+		for _, goFile := range goPackage.Violations {
+			sortViolations(goFile.Violations)
+			var violations []*Violation
+			for _, violation := range goFile.Violations {
+				if keep(goFile, violation) {
+					violations = append(violations, violation)
+				}
+			}
+			goFile.Violations = violations
+			if len(violations) > 0 {
+				goFiles = append(goFiles, goFile)
+			}
+		}
+		sort.Slice(goFiles, func(i, j int) bool { return goFiles[i].FilePath < goFiles[j].FilePath })
+		goPackage.Violations = goFiles
+	}
+	return WithFindings(goPackages)
+}
+
+// sortViolations sorts violations by position and rule.
+func sortViolations(violations []*Violation) {
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].StartOffset != violations[j].StartOffset {
+			return violations[i].StartOffset < violations[j].StartOffset
+		}
+		return violations[i].Type < violations[j].Type
+	})
+}
+
+// fingerprint returns the baseline entry identifying the violation by its rule, file,
+// enclosing function and offending code, instead of the line number.
+func (goFile *GoFile) fingerprint(violation *Violation) (*BaselineEntry, error) {
+	src, err := goFile.source()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &BaselineEntry{
+		Rule:     violation.Type,
+		File:     filepath.ToSlash(goFile.FilePath),
+		Function: goFile.enclosingFunction(violation),
+	}
+	if goFile.config != nil {
+		if relPath, ok := goFile.config.relativePath(goFile.FilePath); ok {
+			entry.File = relPath
+		}
+	}
+	if 0 <= violation.StartOffset && violation.StartOffset <= violation.EndOffset && violation.EndOffset <= len(src) {
+		snippet := src[violation.StartOffset:violation.EndOffset]
+		if newline := bytes.IndexByte(snippet, '\n'); newline >= 0 {
+			snippet = snippet[:newline]
+		}
+		entry.Snippet = normalizeSnippet(snippet)
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join([]string{entry.Rule.String(), entry.File, entry.Function, entry.Snippet}, "\x00")))
+	entry.Fingerprint = hex.EncodeToString(hash[:])
+	return entry, nil
+}
+
+// normalizeSnippet returns the Go tokens in snippet separated by a single space, so changes of
+// whitespace and comments do not change the snippet.
+func normalizeSnippet(snippet []byte) string {
+	fileSet := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fileSet.AddFile("", -1, len(snippet)), snippet, nil, 0)
+
+	var tokens []string
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if lit == "\n" && tok == token.SEMICOLON {
+			continue // Automatically inserted semicolon.
+		}
+		if lit == "" {
+			lit = tok.String()
+		}
+		tokens = append(tokens, lit)
+	}
+	return strings.Join(tokens, " ")
+}
+
+// enclosingFunction returns the name of the function or method declaring the violation,
+// methods are named like "(*Type).Method". Returns the empty string if there is none.
+func (goFile *GoFile) enclosingFunction(violation *Violation) string {
+	file := goFile.File()
+	if file == nil {
+		return ""
+	}
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		start, end := goFile.fileSet.Position(funcDecl.Pos()).Offset, goFile.fileSet.Position(funcDecl.End()).Offset
+		if violation.StartOffset < start || violation.StartOffset >= end {
+			continue
+		}
+		if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+			return funcDecl.Name.Name
+		}
+		return fmt.Sprintf("(%s).%s", receiverType(funcDecl.Recv.List[0].Type), funcDecl.Name.Name)
+	}
+	return ""
+}
+
+// receiverType returns the type name of a method receiver, like "*Type".
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr: // Generic type, the type parameters are left out.
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	}
+	return ""
+}
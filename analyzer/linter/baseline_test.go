@@ -0,0 +1,108 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+const baselineSrc = `package main
+
+import "os"
+
+func main() {
+	x := len(os.Args)
+	if x == 1 {
+	}
+	if x == 2 {
+	}
+}
+`
+
+// The baseline should still accept the legacy violations after lines are added above
+// them and whitespace is changed, only the new violations in main() should be reported.
+const shiftedSrc = `package main
+
+import "os"
+
+// Documentation moving the violations down.
+func helper() {
+}
+
+func main() {
+	x := len(os.Args)
+	if x == 3 {
+	}
+	if x==1 {
+	}
+	if x == 2 {
+	}
+	if x == 2 {
+	}
+}
+`
+
+// analyseSource analyses src written to main.go in dir.
+func analyseSource(t *testing.T, dir string, src string) []*linter.GoPackage {
+	writeModule(t, dir, map[string]string{"main.go": src})
+	goPackages, err := linter.DetectViolations(linter.NewConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return goPackages
+}
+
+func TestBaseline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goanalysis-baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	baseline, err := linter.NewBaseline(analyseSource(t, dir, baselineSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(baseline.Violations) != 2 || baseline.Violations[0].Function != "main" || baseline.Violations[0].Snippet != "if x == 1 {" {
+		t.Fatalf("Baseline should have two fingerprints in main(), but has %d!", len(baseline.Violations))
+	}
+
+	// Write and read back the baseline.
+	baselinePath := filepath.Join(dir, "baseline.json")
+	if err := baseline.WriteFile(baselinePath); err != nil {
+		t.Fatal(err)
+	}
+	if baseline, err = linter.LoadBaseline(baselinePath); err != nil {
+		t.Fatal(err)
+	}
+
+	goPackages, err := baseline.Filter(analyseSource(t, dir, baselineSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(goPackages) != 0 {
+		t.Errorf("All violations should be accepted by the baseline, but %d packages have violations!", len(goPackages))
+	}
+
+	goPackages, err = baseline.Filter(analyseSource(t, dir, shiftedSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(goPackages) != 1 {
+		t.Fatalf("One package should have new violations, but %d packages have!", len(goPackages))
+	}
+	// The second x == 2 is reported as new, the baseline only accepts one.
+	actualViolations := []actualViolation{
+		{SrcLine: 11, Type: linter.EMPTY_IF_BODY},
+		{SrcLine: 17, Type: linter.EMPTY_IF_BODY},
+	}
+	if err := verifyViolations(goPackages[0].Violations[0].Violations, actualViolations); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter
+
+import (
+	"fmt"
+	"go/build"
+	"os"
+	"sort"
+	"strings"
+)
+
+// BuildConfig selects the files of a package like the go command does, by build
+// constraints evaluated for the target operating system, architecture and tags.
+type BuildConfig struct {
+	GOOS   string   // Target operating system, the go command's default if empty.
+	GOARCH string   // Target architecture, the go command's default if empty.
+	Tags   []string // Additional build tags.
+	Tests  bool     // Include _test.go files and external test packages.
+}
+
+// String returns the build configuration like "linux/amd64 tags=integration tests".
+func (buildConfig BuildConfig) String() string {
+	goos, goarch := buildConfig.GOOS, buildConfig.GOARCH
+	if goos == "" {
+		goos = build.Default.GOOS
+	}
+	if goarch == "" {
+		goarch = build.Default.GOARCH
+	}
+	description := fmt.Sprintf("%s/%s", goos, goarch)
+	if len(buildConfig.Tags) > 0 {
+		description += " tags=" + strings.Join(buildConfig.Tags, ",")
+	}
+	if buildConfig.Tests {
+		description += " tests"
+	}
+	return description
+}
+
+// env returns the environment of the go command loading packages for the build configuration.
+func (buildConfig BuildConfig) env() []string {
+	env := os.Environ()
+	if buildConfig.GOOS != "" {
+		env = append(env, "GOOS="+buildConfig.GOOS)
+	}
+	if buildConfig.GOARCH != "" {
+		env = append(env, "GOARCH="+buildConfig.GOARCH)
+	}
+	return env
+}
+
+// buildFlags returns the flags of the go command loading packages for the build configuration.
+func (buildConfig BuildConfig) buildFlags() []string {
+	if len(buildConfig.Tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(buildConfig.Tags, ",")}
+}
+
+// builds returns the build configurations to analyse, the default configuration if none are set.
+func (config *Config) builds() []BuildConfig {
+	if len(config.Builds) == 0 {
+		return []BuildConfig{{}}
+	}
+	return config.Builds
+}
+
+// mergePackages adds the packages analysed under another build configuration in from to
+// goPackages. Violations found under several build configurations are merged into one,
+// listing all the configurations.
+func mergePackages(goPackages []*GoPackage, from []*GoPackage) []*GoPackage {
+	packageKey := func(goPackage *GoPackage) string {
+		return goPackage.Path + "\x00" + goPackage.Pack.Name + "\x00" + goPackage.ImportPath
+	}
+	existingPackages := make(map[string]*GoPackage)
+	for _, goPackage := range goPackages {
+		existingPackages[packageKey(goPackage)] = goPackage
+	}
+
+	for _, fromPackage := range from {
+		goPackage, ok := existingPackages[packageKey(fromPackage)]
+		if !ok {
+			existingPackages[packageKey(fromPackage)] = fromPackage
+			goPackages = append(goPackages, fromPackage)
+			continue
+		}
+
+		for _, fromFile := range fromPackage.Files {
+			if goFile := goPackage.file(fromFile.FilePath); goFile != nil {
+				goFile.mergeViolations(fromFile.Violations)
+			} else {
+				goPackage.Files = append(goPackage.Files, fromFile)
+				if fromFile.goFileNode != nil {
+					goPackage.Pack.Files[fromFile.FilePath] = fromFile.goFileNode
+				}
+			}
+		}
+		goPackage.mergeDiagnostics(fromPackage.Diagnostics)
+		sort.Slice(goPackage.Files, func(i, j int) bool { return goPackage.Files[i].FilePath < goPackage.Files[j].FilePath })
+
+		goPackage.Violations = nil
+		for _, goFile := range goPackage.Files {
+			if len(goFile.Violations) > 0 {
+				goPackage.Violations = append(goPackage.Violations, goFile)
+			}
+		}
+	}
+	return goPackages
+}
+
+// file returns the analysed file in the package at filePath, nil if there is none.
+func (goPackage *GoPackage) file(filePath string) *GoFile {
+	for _, goFile := range goPackage.Files {
+		if goFile.FilePath == filePath {
+			return goFile
+		}
+	}
+	return nil
+}
+
+// mergeViolations adds the violations found under another build configuration to the file,
+// adding the build configurations of violations already found to the existing violation.
+func (goFile *GoFile) mergeViolations(violations []*Violation) {
+	violationKey := func(violation *Violation) string {
+		return fmt.Sprintf("%s\x00%d\x00%d\x00%s", violation.Type, violation.StartOffset, violation.EndOffset, violation.Description)
+	}
+	existingViolations := make(map[string]*Violation)
+	for _, violation := range goFile.Violations {
+		existingViolations[violationKey(violation)] = violation
+	}
+
+	for _, violation := range violations {
+		if existing, ok := existingViolations[violationKey(violation)]; ok {
+			existing.Builds = append(existing.Builds, violation.Builds...)
+		} else {
+			existingViolations[violationKey(violation)] = violation
+			goFile.Violations = append(goFile.Violations, violation)
+		}
+	}
+}
+
+// mergeDiagnostics adds the diagnostics found under another build configuration to the package,
+// adding the build configurations of diagnostics already found to the existing diagnostic.
+func (goPackage *GoPackage) mergeDiagnostics(diagnostics []*Diagnostic) {
+	existingDiagnostics := make(map[string]*Diagnostic)
+	for _, diagnostic := range goPackage.Diagnostics {
+		existingDiagnostics[diagnostic.String()] = diagnostic
+	}
+
+	for _, diagnostic := range diagnostics {
+		if existing, ok := existingDiagnostics[diagnostic.String()]; ok {
+			existing.Builds = append(existing.Builds, diagnostic.Builds...)
+		} else {
+			existingDiagnostics[diagnostic.String()] = diagnostic
+			goPackage.Diagnostics = append(goPackage.Diagnostics, diagnostic)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+func TestBuildConfigString(t *testing.T) {
+	buildConfig := linter.BuildConfig{GOOS: "linux", GOARCH: "arm64", Tags: []string{"a", "b"}, Tests: true}
+	if buildConfig.String() != "linux/arm64 tags=a,b tests" {
+		t.Errorf("Build configuration should be described as %q, and not %q!", "linux/arm64 tags=a,b tests", buildConfig.String())
+	}
+}
+
+// violationBuilds returns the build configurations of the violations in goPackages by file name.
+func violationBuilds(goPackages []*linter.GoPackage) map[string][]string {
+	builds := make(map[string][]string)
+	for _, goPackage := range goPackages {
+		for _, goFile := range goPackage.Violations {
+			for _, violation := range goFile.Violations {
+				builds[filepath.Base(goFile.FilePath)] = append(builds[filepath.Base(goFile.FilePath)], violation.Builds...)
+			}
+		}
+	}
+	return builds
+}
+
+func TestDetectionUnderBuildConfigurations(t *testing.T) {
+	config := linter.NewConfig("./testcode/buildtags")
+	config.Builds = []linter.BuildConfig{
+		{GOOS: "linux", GOARCH: "amd64"},
+		{GOOS: "windows", GOARCH: "amd64", Tags: []string{"custom"}, Tests: true},
+	}
+	goPackages, err := linter.DetectViolations(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(goPackages) != 1 {
+		t.Fatalf("One package should contain violations, but %d packages do!", len(goPackages))
+	}
+	for _, goFile := range goPackages[0].Violations {
+		actualViolations := []actualViolation{{SrcLine: 7, Type: linter.EMPTY_IF_BODY}}
+		if filepath.Base(goFile.FilePath) == "custom.go" {
+			actualViolations[0].SrcLine = 10 // Below the build constraint.
+		}
+		if err := verifyViolations(goFile.Violations, actualViolations); err != nil {
+			t.Fatalf("%s: %s", goFile.FilePath, err)
+		}
+	}
+
+	linux, windows := "linux/amd64", "windows/amd64 tags=custom tests"
+	expectedBuilds := map[string][]string{
+		"common.go":      {linux, windows},
+		"common_test.go": {windows},
+		"custom.go":      {windows},
+		"os_linux.go":    {linux},
+		"os_windows.go":  {windows},
+	}
+	if builds := violationBuilds(goPackages); !reflect.DeepEqual(builds, expectedBuilds) {
+		t.Errorf("Violations should be found under builds %v, and not %v!", expectedBuilds, builds)
+	}
+}
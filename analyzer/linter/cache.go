@@ -0,0 +1,271 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/globalvars"
+	"golang.org/x/tools/go/packages"
+)
+
+// cacheKeyMode loads what the cache key of a package is computed from, the export data
+// of dependencies is compiled by the go command, which caches it in the build cache.
+const cacheKeyMode = packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps |
+	packages.NeedExportFile
+
+// cacheFormat is the version of the cached results, changed when their content changes.
+const cacheFormat = 10
+
+// cacheEntry is the analysis result of a package stored in the cache.
+type cacheEntry struct {
+	Files []*GoFile // All analysed files, sorted by path.
+}
+
+// DefaultCacheDir returns the directory analysis results are cached in by default, being
+// 'goanalysis' in the user's cache directory, like $XDG_CACHE_HOME/goanalysis.
+func DefaultCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "goanalysis"), nil
+}
+
+// loadCachedPackages loads the packages matching config.Patterns like loadPackages, taking the
+// results of packages whose source files, dependencies, configuration and analyzer version are
+// unchanged from the cache in config.CacheDir. Only the other packages are parsed and type
+// checked, their results are stored in the cache once analysed.
+func loadCachedPackages(config *Config, buildConfig BuildConfig, absDir string) ([]*GoPackage, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:       cacheKeyMode,
+		Dir:        config.Dir,
+		Env:        buildConfig.env(),
+		BuildFlags: buildConfig.buildFlags(),
+		Tests:      buildConfig.Tests,
+	}, config.patterns()...)
+	if err != nil {
+		return nil, err
+	}
+
+	var goPackages []*GoPackage
+	cacheKeys := make(map[string]string) // Keys of the packages to analyse by package ID, empty if not to be cached.
+	var patterns []string
+	exportHashes := make(map[string]string)
+	for _, pkg := range selectPackages(pkgs) {
+		var files []string
+		for _, filePath := range pkg.GoFiles {
+			if displayPath, ok := config.includedFile(absDir, filePath); ok {
+				files = append(files, displayPath)
+			}
+		}
+		if len(pkg.GoFiles) == 0 && len(pkg.Errors) > 0 {
+			goPackages = append(goPackages, config.failedPackage(absDir, pkg))
+			continue
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		if len(pkg.Errors) > 0 {
+			cacheKeys[pkg.ID] = "" // Results may be incomplete, analyse without caching them.
+		} else {
+			key, err := config.cacheKey(buildConfig, pkg, files, exportHashes)
+			if err != nil {
+				return nil, err
+			}
+			if entry := config.cachedResult(key); entry != nil {
+				goPackages = append(goPackages, entry.goPackage(config, pkg, files))
+				continue
+			}
+			cacheKeys[pkg.ID] = key
+		}
+		patterns = append(patterns, packagePattern(absDir, filepath.Dir(pkg.GoFiles[0]))) // Also loads the test variants.
+	}
+	if len(patterns) == 0 {
+		return goPackages, nil
+	}
+
+	loadedPackages, err := loadSyntax(config, buildConfig, absDir, uniqueStrings(patterns))
+	if err != nil {
+		return nil, err
+	}
+	for _, goPackage := range loadedPackages {
+		if key, ok := cacheKeys[goPackage.id]; ok {
+			goPackage.cacheKey = key
+			goPackages = append(goPackages, goPackage)
+		}
+	}
+	return goPackages, nil
+}
+
+// packagePattern returns the pattern matching the package in the absolute packageDir, relative
+// to absDir, as GOPATH mode does not accept absolute paths.
+func packagePattern(absDir string, packageDir string) string {
+	relDir, err := filepath.Rel(absDir, packageDir)
+	if err != nil {
+		return packageDir
+	}
+	if strings.HasPrefix(relDir, "..") {
+		return relDir
+	}
+	return "." + string(filepath.Separator) + relDir
+}
+
+// uniqueStrings returns the sorted values without duplicates.
+func uniqueStrings(values []string) []string {
+	sort.Strings(values)
+	var unique []string
+	for i, s := range values {
+		if i == 0 || s != values[i-1] {
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
+
+// cacheKey returns the key of the analysis result of pkg having the files, computed from the
+// analyzer version, the cache format, the registered rules, the configuration, the build
+// configuration, the content of the files and the export data of the imported packages. The
+// hashes of export data files are memoized in exportHashes.
+func (config *Config) cacheKey(buildConfig BuildConfig, pkg *packages.Package, files []string,
+	exportHashes map[string]string) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s cache %d\n%s\n%s %s\n", globalvars.PROGRAM_NAME, globalvars.VERSION, cacheFormat, buildConfig,
+		pkg.ID, pkg.Name)
+	for _, checker := range Checkers() {
+		fmt.Fprintf(hash, "rule %s\n", checker.Info().Rule)
+	}
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(hash, "config %s\n", configJSON)
+
+	for _, filePath := range files {
+		fileHash, err := hashFile(filePath)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "file %s %s\n", filePath, fileHash)
+	}
+
+	importPaths := make([]string, 0, len(pkg.Imports))
+	for importPath := range pkg.Imports {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+	for _, importPath := range importPaths {
+		imported := pkg.Imports[importPath]
+		exportHash, ok := exportHashes[imported.ID]
+		if !ok {
+			exportHash = imported.ID // No export data, like for package unsafe.
+			if imported.ExportFile != "" {
+				if exportHash, err = hashFile(imported.ExportFile); err != nil {
+					return "", err
+				}
+			}
+			exportHashes[imported.ID] = exportHash
+		}
+		fmt.Fprintf(hash, "import %s %s\n", importPath, exportHash)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashFile returns the hex encoded SHA-256 hash of the content of the file at filePath.
+func hashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// cacheFile returns the path of the cache file holding the result having key.
+func (config *Config) cacheFile(key string) string {
+	return filepath.Join(config.CacheDir, key[:2], key+".json")
+}
+
+// cachedResult returns the analysis result cached with key, nil if there is none.
+func (config *Config) cachedResult(key string) *cacheEntry {
+	content, err := ioutil.ReadFile(config.cacheFile(key))
+	if err != nil {
+		return nil
+	}
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(content, entry); err != nil {
+		return nil // Damaged, analyse again and replace it.
+	}
+	return entry
+}
+
+// storeResult stores the analysis result of the package in the cache, if it has a cache key
+// and was analysed without problems.
+func (goPackage *GoPackage) storeResult() error {
+	if goPackage.cacheKey == "" || len(goPackage.Diagnostics) > 0 {
+		return nil
+	}
+	content, err := json.Marshal(&cacheEntry{Files: goPackage.Files})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file renamed into place, so concurrent runs never read half a file.
+	cacheFile := goPackage.config.cacheFile(goPackage.cacheKey)
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+		return err
+	}
+	tempFile, err := ioutil.TempFile(filepath.Dir(cacheFile), "tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = tempFile.Write(content)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempFile.Name(), cacheFile)
+	}
+	if err != nil {
+		os.Remove(tempFile.Name())
+	}
+	return err
+}
+
+// goPackage returns the package pkg having the files analysed as cached in the entry.
+// The files are parsed again only if their syntax tree is asked for.
+func (entry *cacheEntry) goPackage(config *Config, pkg *packages.Package, files []string) *GoPackage {
+	goPackage := &GoPackage{
+		Path:       filepath.Dir(files[0]),
+		ImportPath: pkg.PkgPath,
+		Pack:       &ast.Package{Name: pkg.Name, Files: make(map[string]*ast.File)},
+		config:     config,
+		id:         pkg.ID,
+		cached:     true,
+	}
+	for _, goFile := range entry.Files {
+		goFile.config = config
+		goPackage.Files = append(goPackage.Files, goFile)
+		if len(goFile.Violations) > 0 {
+			goPackage.Violations = append(goPackage.Violations, goFile)
+		}
+	}
+	return goPackage
+}
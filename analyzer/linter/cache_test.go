@@ -0,0 +1,89 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+const cachedSrc = `package main
+
+import "os"
+
+func main() {
+	if len(os.Args) == 1 {
+	}
+}
+`
+
+// cachedViolations analyses the Go source files in dir, caching the results in cacheDir, and
+// returns the descriptions of the violations.
+func cachedViolations(t *testing.T, dir string, cacheDir string, config *linter.Config) []string {
+	config.CacheDir = cacheDir
+	goPackages, err := linter.DetectViolations(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var descriptions []string
+	for _, goPackage := range goPackages {
+		for _, goFile := range goPackage.Violations {
+			for _, violation := range goFile.Violations {
+				descriptions = append(descriptions, violation.Description)
+			}
+		}
+	}
+	return descriptions
+}
+
+// rewriteCache replaces old with new in all files in cacheDir.
+func rewriteCache(t *testing.T, cacheDir string, old string, new string) {
+	err := filepath.Walk(cacheDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, bytes.Replace(content, []byte(old), []byte(new), -1), 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCachedAnalysis(t *testing.T) {
+	dir, cacheDir := t.TempDir(), t.TempDir()
+	writeModule(t, dir, map[string]string{"main.go": cachedSrc})
+
+	descriptions := cachedViolations(t, dir, cacheDir, linter.NewConfig(dir))
+	if len(descriptions) != 1 {
+		t.Fatalf("One violation should be found, but %d are!", len(descriptions))
+	}
+
+	// Unchanged packages are not analysed again, so the altered cache is reported.
+	rewriteCache(t, cacheDir, descriptions[0], "Cached")
+	if descriptions := cachedViolations(t, dir, cacheDir, linter.NewConfig(dir)); len(descriptions) != 1 || descriptions[0] != "Cached" {
+		t.Errorf("The violation should be taken from the cache, and not be %v!", descriptions)
+	}
+
+	// Changed configurations and source files are analysed again.
+	config := linter.NewConfig(dir)
+	config.Rules = map[linter.Rule]*linter.RuleConfig{linter.CYCLOMATIC_COMPLEXITY: {Params: map[string]interface{}{"limit": 5}}}
+	if descriptions := cachedViolations(t, dir, cacheDir, config); len(descriptions) != 1 || descriptions[0] == "Cached" {
+		t.Errorf("The package should be analysed again when the configuration changes, and not give %v!", descriptions)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(cachedSrc+"\nfunc empty() {\n\tfor {\n\t}\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if descriptions := cachedViolations(t, dir, cacheDir, linter.NewConfig(dir)); len(descriptions) != 2 {
+		t.Errorf("The package should be analysed again when the source changes, finding 2 violations and not %v!", descriptions)
+	}
+}
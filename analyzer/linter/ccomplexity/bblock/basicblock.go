@@ -8,455 +8,799 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"log"
+	"path"
 	"sort"
+	"strconv"
 )
 
 type BasicBlockType int
 
-//Basic Block types.
+// Basic Block types, telling which statement a basic-block starts.
 const (
 	FUNCTION_ENTRY BasicBlockType = iota
 	IF_CONDITION
-	ELSE_CONDITION
+	IF_BODY
+	ELSE_BODY
+	IF_DONE
+	FOR_STATEMENT
+	FOR_BODY
+	FOR_POST
+	FOR_DONE
+	RANGE_STATEMENT
+	RANGE_BODY
+	RANGE_DONE
 	SWITCH_STATEMENT
 	CASE_CLAUSE
+	SWITCH_DONE
 	SELECT_STATEMENT
 	COMM_CLAUSE
-	RETURN_STMT
-	FOR_STATEMENT
-	RANGE_STATEMENT
-	GO_STATEMENT
-	CALL_EXPRESSION
-	ELSE_BODY
-	FOR_BODY
-	EMPTY
+	SELECT_DONE
+	LABEL
+	DEFERRED_CALLS
+	UNREACHABLE
 	START
 	EXIT
-	UNKNOWN
+	NO_RETURN
 )
 
 var basicBlockTypeStrings = [...]string{
 	FUNCTION_ENTRY:   "FUNCTION_ENTRY",
 	IF_CONDITION:     "IF_CONDITION",
-	ELSE_CONDITION:   "ELSE_CONDITION",
+	IF_BODY:          "IF_BODY",
+	ELSE_BODY:        "ELSE_BODY",
+	IF_DONE:          "IF_DONE",
+	FOR_STATEMENT:    "FOR_STATEMENT",
+	FOR_BODY:         "FOR_BODY",
+	FOR_POST:         "FOR_POST",
+	FOR_DONE:         "FOR_DONE",
+	RANGE_STATEMENT:  "RANGE_STATEMENT",
+	RANGE_BODY:       "RANGE_BODY",
+	RANGE_DONE:       "RANGE_DONE",
 	SWITCH_STATEMENT: "SWITCH_STATEMENT",
 	CASE_CLAUSE:      "CASE_CLAUSE",
+	SWITCH_DONE:      "SWITCH_DONE",
 	SELECT_STATEMENT: "SELECT_STATEMENT",
 	COMM_CLAUSE:      "COMM_CLAUSE",
-	RETURN_STMT:      "RETURN_STMT",
-	FOR_STATEMENT:    "FOR_STATEMENT",
-	RANGE_STATEMENT:  "RANGE_STATEMENT",
-	GO_STATEMENT:     "GO_STATEMENT",
-	CALL_EXPRESSION:  "CALL_EXPRESSION",
-	ELSE_BODY:        "ELSE_BODY",
-	FOR_BODY:         "FOR_BODY",
-	EMPTY:            "EMPTY",
+	SELECT_DONE:      "SELECT_DONE",
+	LABEL:            "LABEL",
+	DEFERRED_CALLS:   "DEFERRED_CALLS",
+	UNREACHABLE:      "UNREACHABLE",
 	START:            "Start",
 	EXIT:             "Exit",
-	UNKNOWN:          "UNKNOWN",
+	NO_RETURN:        "NoReturn",
+}
+
+// noReturnFuncs are the functions not returning to the caller by package path, telling whether
+// deferred calls run, as they do on a panic, or the program exits at once.
+var noReturnFuncs = map[string]map[string]bool{
+	"os":  {"Exit": false},
+	"log": {"Fatal": false, "Fatalf": false, "Fatalln": false, "Panic": true, "Panicf": true, "Panicln": true},
 }
 
 func (bbType BasicBlockType) String() string {
 	return basicBlockTypeStrings[bbType]
 }
 
+// BasicBlock is a sequence of statements always executed in order from the first to the last,
+// entered only at the first statement and left only after the last.
+type BasicBlock struct {
+	Number           int
+	Type             BasicBlockType
+	Stmts            []ast.Stmt // Statements in execution order, see AddStmt.
+	Start            token.Pos  // Start of the first statement, or where an empty block is.
+	End              token.Pos  // End of the last statement, or where an empty block is.
+	StartLine        int
+	EndLine          int
+	successor        []*BasicBlock
+	pos              token.Pos // Position of the statement starting the block, ordering the blocks.
+	FunctionName     string    // Name of the function a FUNCTION_ENTRY block enters, like main, (*T).M or main.func1.
+	FunctionDeclLine int
+	Function         ast.Node // Declaration or literal of the function a FUNCTION_ENTRY block enters.
+	Recovers         bool     // A DEFERRED_CALLS block calls a function literal calling recover.
+}
+
 func (basicBlock *BasicBlock) UID() string {
-	//Both START and EXIT blocks are meta-blocks, giving them negative UID which will not be confused with 'real' blocks.
-	if basicBlock.Type == START || basicBlock.Type == EXIT {
+	//START, EXIT and NO_RETURN blocks are meta-blocks, giving them negative UID which will not be confused with 'real' blocks.
+	if basicBlock.Type == START || basicBlock.Type == EXIT || basicBlock.Type == NO_RETURN {
 		return fmt.Sprintf("%d", 0-basicBlock.Type)
 	}
-	return fmt.Sprintf("%d", basicBlock.EndLine)
+	return fmt.Sprintf("%d", basicBlock.Number)
 }
 
 func (basicBlock *BasicBlock) String() string {
-	if basicBlock.Type == START {
-		return basicBlock.Type.String()
-	} else if basicBlock.Type == EXIT {
+	if basicBlock.Type == START || basicBlock.Type == EXIT || basicBlock.Type == NO_RETURN {
 		return basicBlock.Type.String()
 	}
-	return fmt.Sprintf("BLOCK NR.%d (%s) (EndLine: %d)", basicBlock.Number, basicBlock.Type.String(), basicBlock.EndLine)
+	return fmt.Sprintf("BLOCK NR.%d (%s) (Lines: %d-%d)", basicBlock.Number, basicBlock.Type.String(),
+		basicBlock.StartLine, basicBlock.EndLine)
 }
 
+// AddSuccessorBlock adds edges to the successor blocks, in order. Existing edges are not added again.
 func (basicBlock *BasicBlock) AddSuccessorBlock(successorBlocks ...*BasicBlock) {
 	for _, successorBlock := range successorBlocks {
-		basicBlock.successor[successorBlock.EndLine] = successorBlock //Update or add.
+		if !basicBlock.hasSuccessor(successorBlock) {
+			basicBlock.successor = append(basicBlock.successor, successorBlock)
+		}
 	}
 }
 
-func NewBasicBlock(blockNumber int, blockType BasicBlockType, endLine int) *BasicBlock {
-	return &BasicBlock{Number: blockNumber, Type: blockType, EndLine: endLine, successor: map[int]*BasicBlock{}}
-}
-
-func (basicBlock *BasicBlock) GetSuccessorBlocks() []*BasicBlock {
-	keys := make([]int, len(basicBlock.successor))
-	basicBlocks := []*BasicBlock{}
-
-	i := 0
-	for k := range basicBlock.successor {
-		keys[i] = k
-		i++
+func (basicBlock *BasicBlock) hasSuccessor(successorBlock *BasicBlock) bool {
+	for _, block := range basicBlock.successor {
+		if block == successorBlock {
+			return true
+		}
 	}
-	sort.Ints(keys) //Sort keys from map.
+	return false
+}
 
-	//Add the basic-block into the array.
-	for _, key := range keys {
-		basicBlocks = append(basicBlocks, basicBlock.successor[key])
+// AddStmt appends stmt to the statements of the block. If, for, range, switch and select statements
+// end the block they branch from, only their header (init, condition, range or tag) is executed there.
+func (basicBlock *BasicBlock) AddStmt(stmt ast.Stmt) {
+	if len(basicBlock.Stmts) == 0 {
+		basicBlock.Start = stmt.Pos()
 	}
-	return basicBlocks
+	basicBlock.Stmts = append(basicBlock.Stmts, stmt)
+	basicBlock.End = stmt.End()
 }
 
-type BasicBlock struct {
-	Number           int
-	Type             BasicBlockType
-	EndLine          int
-	successor        map[int]*BasicBlock
-	FunctionName     string
-	FunctionDeclLine int
+func NewBasicBlock(blockNumber int, blockType BasicBlockType, endLine int) *BasicBlock {
+	return &BasicBlock{Number: blockNumber, Type: blockType, EndLine: endLine}
 }
 
-type visitor struct {
-	basicBlocks   map[int]*BasicBlock
-	sourceFileSet *token.FileSet
-
-	lastBlock     *BasicBlock
-	prevLastBlock *BasicBlock
-
-	returnBlock  *BasicBlock
-	forBlock     *BasicBlock
-	forBodyBlock *BasicBlock
-	switchBlock  *BasicBlock
+// GetSuccessorBlocks returns the blocks control may flow to after this block, in the order they were added.
+func (basicBlock *BasicBlock) GetSuccessorBlocks() []*BasicBlock {
+	return basicBlock.successor
 }
 
-// UpdateBasicBlock updates all the variables from the newBasicBlock into the basicBlock object.
-func (basicBlock *BasicBlock) UpdateBasicBlock(newBasicBlock *BasicBlock) {
-	if newBasicBlock != nil {
-		basicBlock.Number = newBasicBlock.Number
-		basicBlock.Type = newBasicBlock.Type
-		basicBlock.EndLine = newBasicBlock.EndLine
-		basicBlock.successor = newBasicBlock.successor
-		basicBlock.FunctionName = newBasicBlock.FunctionName
-		basicBlock.FunctionDeclLine = newBasicBlock.FunctionDeclLine
-	}
+// builder builds the basic-blocks of a function body.
+type builder struct {
+	blocks           []*BasicBlock
+	current          *BasicBlock       // Block statements are added to, nil if control can not reach the next statement.
+	exit             *BasicBlock       // Successor of blocks leaving the function.
+	noReturn         *BasicBlock       // Successor of blocks panicking or exiting the program, followed by exit.
+	imports          map[string]string // Import paths by the name the file refers to them, nil if unknown.
+	info             *types.Info       // Resolves the called functions if not nil.
+	exits            []exitEdge        // Edges leaving the function, added once the deferred calls are known.
+	bodyEnd          token.Pos
+	labels           map[string]*BasicBlock
+	label            string    // Label of the statement being added, if any.
+	targets          []*target // Enclosing statements break and continue statements may branch to, innermost last.
+	fallthroughBlock *BasicBlock
 }
 
-func (v *visitor) AddBasicBlock(blockType BasicBlockType, position token.Pos) *BasicBlock {
-	line := v.sourceFileSet.File(position).Line(position)
-	basicBlock := NewBasicBlock(-1, blockType, line) //-1 indicates number will be set later.
+// target holds the blocks a break or continue statement in a for, range, switch or select statement
+// branches to, continueBlock is nil for switch and select statements.
+type target struct {
+	label         string
+	breakBlock    *BasicBlock
+	continueBlock *BasicBlock
+}
 
-	// Bookkeeping.
-	v.prevLastBlock = v.lastBlock
-	v.lastBlock = basicBlock
+// exitEdge is an edge from block out of the function to target, exit or noReturn.
+type exitEdge struct {
+	block  *BasicBlock
+	target *BasicBlock
+}
 
-	//Update the existing block., or add new block.
-	if bb, ok := v.basicBlocks[line]; ok {
-		bb.UpdateBasicBlock(basicBlock)
-		v.lastBlock = bb
-		return bb
-	} else {
-		v.basicBlocks[line] = basicBlock
-	}
+// newBlock returns a new empty block at pos.
+func (b *builder) newBlock(blockType BasicBlockType, pos token.Pos) *BasicBlock {
+	basicBlock := &BasicBlock{Number: -1, Type: blockType, Start: pos, End: pos, pos: pos} //-1 indicates number will be set later.
+	b.blocks = append(b.blocks, basicBlock)
 	return basicBlock
 }
 
-// GetBasicBlocks converts map holding the basic-blocks to the ordered set
-// of basic-blocks, in right order!
-func (v *visitor) GetBasicBlocks() []*BasicBlock {
-	keys := make([]int, len(v.basicBlocks))
-	basicBlocks := make([]*BasicBlock, len(v.basicBlocks))
-
-	i := 0
-	for k := range v.basicBlocks {
-		keys[i] = k
-		i++
+// jump adds an edge from the current block to target, and leaves the current block.
+func (b *builder) jump(target *BasicBlock) {
+	if b.current != nil {
+		b.current.AddSuccessorBlock(target)
 	}
-	sort.Ints(keys) //Sort keys from map.
+	b.current = nil
+}
 
-	//Add the basic-block into the array.
-	for index, key := range keys {
-		basicBlocks[index] = v.basicBlocks[key]
-		basicBlocks[index].Number = index //Set basic-block number.
+// add adds stmt to the current block, or to a new unreachable block if control can not reach it.
+func (b *builder) add(stmt ast.Stmt) {
+	if b.current == nil {
+		b.current = b.newBlock(UNREACHABLE, stmt.Pos())
 	}
-	return basicBlocks
+	b.current.AddStmt(stmt)
 }
 
-func GetBasicBlocksFromSourceCode(filePath string, srcFile []byte) ([]*BasicBlock, error) {
-	fileSet := token.NewFileSet()
-	file, err := parser.ParseFile(fileSet, filePath, srcFile, parser.ParseComments|parser.AllErrors)
-	if err != nil {
-		return nil, fmt.Errorf("Parse error: %s", err)
+// branch jumps to a new block ending with the header of stmt, which the body blocks branch from.
+func (b *builder) branch(blockType BasicBlockType, stmt ast.Stmt, init ast.Stmt, body *ast.BlockStmt) *BasicBlock {
+	header := b.newBlock(blockType, stmt.Pos())
+	b.jump(header)
+	if init != nil {
+		header.AddStmt(init)
 	}
+	header.AddStmt(stmt)
+	header.Start, header.End = stmt.Pos(), body.Lbrace
+	return header
+}
 
-	visitor := &visitor{sourceFileSet: fileSet, basicBlocks: make(map[int]*BasicBlock)}
-	ast.Walk(visitor, file)
-
-	basicBlocks := visitor.GetBasicBlocks()
+// body adds the statements in the body of a statement with label, which break and continue statements
+// in body branch out of to breakBlock and continueBlock. Control continues with continueBlock if set.
+func (b *builder) body(label string, stmts []ast.Stmt, breakBlock *BasicBlock, continueBlock *BasicBlock) {
+	b.targets = append(b.targets, &target{label: label, breakBlock: breakBlock, continueBlock: continueBlock})
+	b.stmtList(stmts)
+	b.targets = b.targets[:len(b.targets)-1]
+	if continueBlock != nil {
+		b.jump(continueBlock)
+	}
+}
 
-	numberOfBasicBlocks := len(basicBlocks)
-	for index, bBlock := range basicBlocks {
-		if bBlock.Type != FOR_BODY && bBlock.Type != ELSE_CONDITION && bBlock.Type != ELSE_BODY && bBlock.Type != COMM_CLAUSE && bBlock.Type != CASE_CLAUSE && bBlock.Type != RETURN_STMT {
-			if numberOfBasicBlocks > index+1 {
-				bBlock.AddSuccessorBlock(basicBlocks[index+1])
-			}
+// branchTarget returns the block the break or continue statement branches to, nil if there is none.
+func (b *builder) branchTarget(branchStmt *ast.BranchStmt) *BasicBlock {
+	for i := len(b.targets) - 1; i >= 0; i-- {
+		t := b.targets[i]
+		if branchStmt.Label != nil && branchStmt.Label.Name != t.label {
+			continue
+		}
+		if branchStmt.Tok == token.BREAK {
+			return t.breakBlock
+		} else if t.continueBlock != nil {
+			return t.continueBlock
+		}
+		if branchStmt.Label != nil {
+			return nil // Only loops can be continued.
 		}
 	}
+	return nil
+}
 
-	return basicBlocks, nil
+// scan adds a LABEL block for every labeled statement in the function body, so goto statements can
+// branch to labels further down.
+func (b *builder) scan(body *ast.BlockStmt) {
+	b.labels = make(map[string]*BasicBlock)
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncLit:
+			return false // Labels are local to the function literal.
+		case *ast.LabeledStmt:
+			b.labels[n.Label.Name] = b.newBlock(LABEL, n.Pos())
+		}
+		return true
+	})
 }
 
-func PrintBasicBlocks(basicBlocks []*BasicBlock) {
-	for _, bb := range basicBlocks {
-		log.Printf("%d) %s (EndLine: %d)\n", bb.Number, bb.Type.String(), bb.EndLine)
+// callsRecover returns whether the function body calls recover, not counting nested function literals.
+func (b *builder) callsRecover(body *ast.BlockStmt) (recovers bool) {
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			if ident, ok := n.Fun.(*ast.Ident); ok && b.isBuiltin(ident, "recover") {
+				recovers = true
+			}
+		}
+		return !recovers
+	})
+	return recovers
+}
 
-		for _, sBB := range bb.GetSuccessorBlocks() {
-			log.Printf("\t-> (%d) %s (EndLine: %d)\n", sBB.Number, sBB.Type.String(), sBB.EndLine)
+// isBuiltin returns whether ident refers to the builtin function name, assumed if there is no type
+// information about it.
+func (b *builder) isBuiltin(ident *ast.Ident, name string) bool {
+	if b.info != nil {
+		if object := b.info.Uses[ident]; object != nil {
+			_, ok := object.(*types.Builtin)
+			return ok && object.Name() == name
 		}
 	}
+	return ident.Name == name
 }
 
-//TODO: Check after all basic-block types we have declared.
-func GetBasicBlockTypeFromStmt(stmtList []ast.Stmt) (BasicBlockType, ast.Stmt) {
-	for _, stmt := range stmtList {
-		switch stmt.(type) {
-		case *ast.ReturnStmt:
-			return RETURN_STMT, stmt
-		case *ast.CaseClause:
-			return CASE_CLAUSE, stmt
-		case *ast.SwitchStmt:
-			return SWITCH_STATEMENT, stmt
+// noReturnCall returns whether stmt calls panic or a function in noReturnFuncs, and whether
+// deferred calls run before leaving the function.
+func (b *builder) noReturnCall(stmt *ast.ExprStmt) (noReturn bool, runsDeferred bool) {
+	call, ok := stmt.X.(*ast.CallExpr)
+	if !ok {
+		return false, false
+	}
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return b.isBuiltin(fun, "panic"), true
+	case *ast.SelectorExpr:
+		runsDeferred, noReturn = noReturnFuncs[b.packagePath(fun)][fun.Sel.Name]
+		return noReturn, runsDeferred
+	}
+	return false, false
+}
+
+// packagePath returns the import path of the package of the function selected by selector, or "" if
+// it is not a package-level function. Without type information the package is resolved by the name
+// the file imports it as, or the name itself.
+func (b *builder) packagePath(selector *ast.SelectorExpr) string {
+	if b.info != nil {
+		if function, ok := b.info.Uses[selector.Sel].(*types.Func); ok {
+			if function.Pkg() == nil || function.Type().(*types.Signature).Recv() != nil {
+				return ""
+			}
+			return function.Pkg().Path()
 		}
 	}
-	return UNKNOWN, nil
+	pkg, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	if importPath, ok := b.imports[pkg.Name]; ok {
+		return importPath
+	}
+	return pkg.Name
 }
 
-func (v *visitor) Visit(node ast.Node) ast.Visitor {
-	if node != nil {
-		switch t := node.(type) {
+// leave jumps out of the function to target, exit or noReturn, through the deferred calls registered
+// on the way. The edge is added by connectExits, once the whole body is added.
+func (b *builder) leave(target *BasicBlock) {
+	if b.current != nil {
+		b.exits = append(b.exits, exitEdge{block: b.current, target: target})
+	}
+	b.current = nil
+}
 
-		case *ast.FuncDecl:
-			funcDeclBlock := v.AddBasicBlock(FUNCTION_ENTRY, t.Pos())
-			// Set information about Function in the block.
-			funcDeclBlock.FunctionName = t.Name.Name
-			funcDeclBlock.FunctionDeclLine = v.sourceFileSet.File(t.Pos()).Line(t.Pos())
+// connectExits connects the blocks leaving the function to their target, through a block running
+// the defer statements registered on every path to them in reverse order. Blocks running the same
+// deferred calls before the same target are shared. After a panic, control returns normally if a
+// deferred function recovers.
+func (b *builder) connectExits() {
+	registered := b.registeredDefers()
+	deferredCalls := make(map[string]*BasicBlock)
+	for _, exit := range b.exits {
+		defers, ok := registered[exit.block]
+		if !ok {
+			continue // Removed by removeDeadEmptyBlocks.
+		}
+		if len(defers) == 0 {
+			exit.block.AddSuccessorBlock(exit.target)
+			continue
+		}
 
-			if t.Body != nil {
-				for _, s := range t.Body.List {
-					if _, ok := s.(*ast.ReturnStmt); ok {
-						v.returnBlock = v.AddBasicBlock(RETURN_STMT, s.End())
-					}
+		key := exit.target.Type.String()
+		for _, deferStmt := range defers {
+			key += fmt.Sprintf(" %d", deferStmt.Pos())
+		}
+		deferred := deferredCalls[key]
+		if deferred == nil {
+			deferred = b.newBlock(DEFERRED_CALLS, b.bodyEnd)
+			for i := len(defers) - 1; i >= 0; i-- {
+				deferred.AddStmt(defers[i]) // Run in reverse order.
+				if funcLit, ok := defers[i].Call.Fun.(*ast.FuncLit); ok && b.callsRecover(funcLit.Body) {
+					deferred.Recovers = true
 				}
+			}
+			deferred.Start, deferred.End = b.bodyEnd, b.bodyEnd
+			deferred.AddSuccessorBlock(exit.target)
+			if exit.target == b.noReturn && deferred.Recovers {
+				deferred.AddSuccessorBlock(b.exit)
+			}
+			deferredCalls[key] = deferred
+		}
+		exit.block.AddSuccessorBlock(deferred)
+	}
+}
 
-				if v.returnBlock == nil {
-					v.returnBlock = v.AddBasicBlock(RETURN_STMT, t.End())
-				}
+// registeredDefers returns the defer statements registered on every path from the function entry
+// to the end of each block, in the order they are registered. Blocks control can not flow into
+// start without registered defer statements.
+func (b *builder) registeredDefers() map[*BasicBlock][]*ast.DeferStmt {
+	predecessors := make(map[*BasicBlock][]*BasicBlock)
+	for _, basicBlock := range b.blocks {
+		for _, successorBlock := range basicBlock.successor {
+			predecessors[successorBlock] = append(predecessors[successorBlock], basicBlock)
+		}
+	}
 
-				//Visit all statements in body.
-				for _, s := range t.Body.List {
-					v.Visit(s)
+	registered := make(map[*BasicBlock][]*ast.DeferStmt)
+	for changed := true; changed; {
+		changed = false
+		for _, basicBlock := range b.blocks {
+			var defers []*ast.DeferStmt
+			known := len(predecessors[basicBlock]) == 0
+			for _, predecessor := range predecessors[basicBlock] {
+				predecessorDefers, ok := registered[predecessor]
+				if !ok {
+					continue // Not reached yet, assumed to register every defer statement.
+				}
+				if known {
+					defers = intersectDefers(defers, predecessorDefers)
+				} else {
+					defers, known = predecessorDefers, true
 				}
 			}
-
-			v.returnBlock = nil
-			return nil
-
-		case *ast.ReturnStmt:
-			prevBlock := v.lastBlock
-			v.returnBlock = v.AddBasicBlock(RETURN_STMT, t.Pos())
-
-			if prevBlock != nil && prevBlock.Type != RETURN_STMT && len(prevBlock.successor) == 0 {
-				prevBlock.AddSuccessorBlock(v.returnBlock)
+			if !known {
+				continue
 			}
 
-			if v.switchBlock != nil {
-				v.switchBlock.AddSuccessorBlock(v.returnBlock)
+			defers = append([]*ast.DeferStmt(nil), defers...)
+			for _, stmt := range basicBlock.Stmts {
+				if deferStmt, ok := stmt.(*ast.DeferStmt); ok {
+					defers = append(defers, deferStmt)
+				}
 			}
-
-		case *ast.GoStmt:
-			v.AddBasicBlock(GO_STATEMENT, t.Pos())
-
-		case *ast.IfStmt:
-			ifBlock := v.AddBasicBlock(IF_CONDITION, t.Pos())
-
-			for _, stmt := range t.Body.List {
-				v.Visit(stmt)
+			if previous, ok := registered[basicBlock]; !ok || len(previous) != len(defers) {
+				registered[basicBlock] = defers
+				changed = true
 			}
+		}
+	}
+	return registered
+}
 
-			var elseConditionBlock, elseBodyBlock *BasicBlock
-			if t.Else != nil {
+// intersectDefers returns the defer statements in both a and b, in the order of a.
+func intersectDefers(a []*ast.DeferStmt, b []*ast.DeferStmt) []*ast.DeferStmt {
+	var defers []*ast.DeferStmt
+	for _, deferA := range a {
+		for _, deferB := range b {
+			if deferA == deferB {
+				defers = append(defers, deferA)
+				break
+			}
+		}
+	}
+	return defers
+}
 
-				if v.lastBlock != nil && v.lastBlock.Type != RETURN_STMT {
-					elseConditionBlock = v.AddBasicBlock(ELSE_CONDITION, t.Else.Pos())
-				} else {
-					// We don't want to set return block as successor to elseCondition.
-					v.returnBlock = nil
-				}
+func (b *builder) stmtList(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		b.stmt(stmt)
+	}
+}
 
-				elseBodyBlock = v.AddBasicBlock(ELSE_BODY, t.Else.End())
-				ifBlock.AddSuccessorBlock(elseBodyBlock)
+func (b *builder) stmt(stmt ast.Stmt) {
+	label := b.label
+	b.label = ""
+
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		b.stmtList(s.List)
+
+	case *ast.LabeledStmt:
+		labelBlock := b.labels[s.Label.Name]
+		b.jump(labelBlock)
+		b.current = labelBlock
+		b.label = s.Label.Name
+		b.stmt(s.Stmt)
+
+	case *ast.ReturnStmt:
+		b.add(s)
+		b.leave(b.exit)
+
+	case *ast.ExprStmt:
+		b.add(s)
+		if noReturn, runsDeferred := b.noReturnCall(s); noReturn && runsDeferred {
+			b.leave(b.noReturn)
+		} else if noReturn {
+			b.jump(b.noReturn)
+		}
 
-				if v.returnBlock != nil {
-					if elseConditionBlock != nil {
-						elseConditionBlock.AddSuccessorBlock(v.returnBlock)
-					}
-					elseBodyBlock.AddSuccessorBlock(v.returnBlock)
-				}
-			}
+	case *ast.BranchStmt:
+		b.add(s)
+		var targetBlock *BasicBlock
+		switch s.Tok {
+		case token.BREAK, token.CONTINUE:
+			targetBlock = b.branchTarget(s)
+		case token.GOTO:
+			targetBlock = b.labels[s.Label.Name]
+		case token.FALLTHROUGH:
+			targetBlock = b.fallthroughBlock
+		}
+		if targetBlock != nil { // Missing in code not type checked.
+			b.jump(targetBlock)
+		}
 
-		case *ast.ForStmt:
-			v.forBlock = v.AddBasicBlock(FOR_STATEMENT, t.Pos())
+	case *ast.IfStmt:
+		header := b.branch(IF_CONDITION, s, s.Init, s.Body)
+		b.current = b.newBlock(IF_BODY, s.Body.Lbrace)
+		header.AddSuccessorBlock(b.current)
+		b.stmtList(s.Body.List)
+		bodyEnd := b.current
+
+		var elseEnd *BasicBlock
+		if elseIf, ok := s.Else.(*ast.IfStmt); ok {
+			b.current = header // The else if condition follows directly.
+			b.stmt(elseIf)
+			elseEnd = b.current
+		} else if s.Else != nil {
+			b.current = b.newBlock(ELSE_BODY, s.Else.Pos())
+			header.AddSuccessorBlock(b.current)
+			b.stmt(s.Else)
+			elseEnd = b.current
+		}
 
-			if v.returnBlock != nil {
-				v.forBlock.AddSuccessorBlock(v.returnBlock)
-			}
+		done := b.newBlock(IF_DONE, s.End())
+		if s.Else == nil {
+			header.AddSuccessorBlock(done)
+		}
+		b.current = bodyEnd
+		b.jump(done)
+		b.current = elseEnd
+		b.jump(done)
+		b.current = done
+
+	case *ast.ForStmt:
+		if s.Init != nil {
+			b.add(s.Init)
+		}
+		header := b.branch(FOR_STATEMENT, s, nil, s.Body)
+		body := b.newBlock(FOR_BODY, s.Body.Lbrace)
+		done := b.newBlock(FOR_DONE, s.End())
+		header.AddSuccessorBlock(body)
+		if s.Cond != nil {
+			header.AddSuccessorBlock(done)
+		}
+		next := header
+		if s.Post != nil {
+			next = b.newBlock(FOR_POST, s.Body.Rbrace) // Runs after the body.
+			next.AddStmt(s.Post)
+			next.AddSuccessorBlock(header)
+		}
 
-			tmpReturnBlock := v.returnBlock
-			tmpForBlock := v.forBlock
-			v.returnBlock = v.forBlock
+		b.current = body
+		b.body(label, s.Body.List, done, next)
+		b.current = done
 
-			for _, s := range t.Body.List {
-				v.Visit(s)
-			}
+	case *ast.RangeStmt:
+		header := b.branch(RANGE_STATEMENT, s, nil, s.Body)
+		body := b.newBlock(RANGE_BODY, s.Body.Lbrace)
+		done := b.newBlock(RANGE_DONE, s.End())
+		header.AddSuccessorBlock(body, done)
 
-			v.returnBlock = tmpReturnBlock
-			v.forBlock = tmpForBlock
+		b.current = body
+		b.body(label, s.Body.List, done, header)
+		b.current = done
 
-			if v.lastBlock.Type == FOR_STATEMENT {
-				v.AddBasicBlock(FOR_BODY, t.End())
-			}
+	case *ast.SwitchStmt:
+		b.caseClauses(label, b.branch(SWITCH_STATEMENT, s, s.Init, s.Body), s.Body, SWITCH_DONE)
 
-			if v.lastBlock.Type != RETURN_STMT {
-				v.lastBlock.AddSuccessorBlock(v.forBlock)
-			}
+	case *ast.TypeSwitchStmt:
+		b.caseClauses(label, b.branch(SWITCH_STATEMENT, s, s.Init, s.Body), s.Body, SWITCH_DONE)
 
-			v.forBlock = nil
-			return nil
+	case *ast.SelectStmt:
+		b.caseClauses(label, b.branch(SELECT_STATEMENT, s, nil, s.Body), s.Body, SELECT_DONE)
 
-		case *ast.SwitchStmt:
-			v.switchBlock = v.AddBasicBlock(SWITCH_STATEMENT, t.Pos())
-			if v.forBlock != nil {
-				v.forBlock.AddSuccessorBlock(v.switchBlock)
-				v.switchBlock.AddSuccessorBlock(v.forBlock)
-			}
+	default:
+		b.add(s)
+	}
+}
 
-			if v.returnBlock != nil {
-				v.switchBlock.AddSuccessorBlock(v.returnBlock)
-			}
+// caseClauses adds a block for each case clause in body, branched to from header. Control continues
+// after the clauses, also from the header if no clause is the default of a switch statement. A fallthrough
+// statement branches to the next clause.
+func (b *builder) caseClauses(label string, header *BasicBlock, body *ast.BlockStmt, doneType BasicBlockType) {
+	hasDefault := header.Type == SELECT_STATEMENT // A select statement blocks until a clause is selected.
+	clauseBlocks := make([]*BasicBlock, len(body.List))
+	for i, stmt := range body.List {
+		if clause, ok := stmt.(*ast.CaseClause); ok {
+			clauseBlocks[i] = b.newBlock(CASE_CLAUSE, clause.Pos())
+			hasDefault = hasDefault || clause.List == nil
+		} else {
+			clauseBlocks[i] = b.newBlock(COMM_CLAUSE, stmt.Pos())
+		}
+		header.AddSuccessorBlock(clauseBlocks[i])
+	}
+	done := b.newBlock(doneType, body.End())
+	if !hasDefault {
+		header.AddSuccessorBlock(done)
+	}
 
-			for _, s := range t.Body.List {
-				v.Visit(s)
-			}
-			return nil
+	outerFallthrough := b.fallthroughBlock
+	for i, stmt := range body.List {
+		b.fallthroughBlock = nil
+		if i+1 < len(clauseBlocks) {
+			b.fallthroughBlock = clauseBlocks[i+1]
+		}
 
-		case *ast.TypeSwitchStmt:
-			v.switchBlock = v.AddBasicBlock(SWITCH_STATEMENT, t.Pos())
-			if v.forBlock != nil {
-				v.forBlock.AddSuccessorBlock(v.switchBlock)
-				v.switchBlock.AddSuccessorBlock(v.forBlock)
+		b.current = clauseBlocks[i]
+		switch clause := stmt.(type) {
+		case *ast.CaseClause:
+			b.body(label, clause.Body, done, nil)
+		case *ast.CommClause:
+			if clause.Comm != nil {
+				b.add(clause.Comm)
 			}
+			b.body(label, clause.Body, done, nil)
+		}
+		b.jump(done)
+	}
+	b.fallthroughBlock = outerFallthrough
+	b.current = done
+}
 
-			for _, s := range t.Body.List {
-				v.Visit(s)
+// removeDeadEmptyBlocks removes the empty blocks control can not flow into, left behind when
+// all branches of a statement leave it.
+func (b *builder) removeDeadEmptyBlocks() {
+	for removed := true; removed; {
+		predecessors := make(map[*BasicBlock]int)
+		for _, basicBlock := range b.blocks {
+			for _, successorBlock := range basicBlock.successor {
+				predecessors[successorBlock]++
 			}
-			return nil
+		}
 
-		case *ast.SelectStmt:
-			v.switchBlock = v.AddBasicBlock(SELECT_STATEMENT, t.Pos())
-			if v.forBlock != nil {
-				v.forBlock.AddSuccessorBlock(v.switchBlock)
-				v.switchBlock.AddSuccessorBlock(v.forBlock)
+		removed = false
+		blocks := b.blocks[:1] // Keep FUNCTION_ENTRY.
+		for _, basicBlock := range b.blocks[1:] {
+			if len(basicBlock.Stmts) == 0 && predecessors[basicBlock] == 0 {
+				removed = true
+			} else {
+				blocks = append(blocks, basicBlock)
 			}
+		}
+		b.blocks = blocks
+	}
+}
 
-			for _, s := range t.Body.List {
-				v.Visit(s)
-			}
-			return nil
+// functionBlocks returns the basic-blocks of the function declaration or literal, in the order they start
+// in the source. Packages are resolved by the names imports refers to them and called functions through info,
+// both may be nil.
+func functionBlocks(fileSet *token.FileSet, name string, function ast.Node, body *ast.BlockStmt, imports map[string]string,
+	info *types.Info) []*BasicBlock {
+	b := &builder{
+		exit:     NewBasicBlock(-1, EXIT, 0),
+		noReturn: NewBasicBlock(-1, NO_RETURN, 0),
+		imports:  imports,
+		info:     info,
+	}
+	b.noReturn.AddSuccessorBlock(b.exit)
+	entry := b.newBlock(FUNCTION_ENTRY, function.Pos())
+	entry.FunctionName = name
+	entry.Function = function
+	entry.FunctionDeclLine = fileSet.Position(function.Pos()).Line
+	if body != nil {
+		entry.Start, entry.End = body.Lbrace, body.Lbrace
+	}
 
-		case *ast.CaseClause:
-			var caseClause *BasicBlock
-			if basicBlockType, s := GetBasicBlockTypeFromStmt(t.Body); basicBlockType != UNKNOWN {
-				caseClause = v.AddBasicBlock(basicBlockType, s.Pos())
-			} else {
-				caseClause = v.AddBasicBlock(CASE_CLAUSE, t.End())
-			}
+	b.current = entry
+	if body != nil {
+		b.bodyEnd = body.Rbrace
+		b.scan(body)
+		b.stmtList(body.List)
+	}
+	b.leave(b.exit)
+	b.removeDeadEmptyBlocks()
+	b.connectExits()
+
+	sort.SliceStable(b.blocks, func(i, j int) bool { return b.blocks[i].pos < b.blocks[j].pos })
+	for _, basicBlock := range b.blocks {
+		basicBlock.StartLine = fileSet.Position(basicBlock.Start).Line
+		basicBlock.EndLine = fileSet.Position(basicBlock.End).Line
+	}
+	return b.blocks
+}
 
-			if v.forBlock != nil {
-				caseClause.AddSuccessorBlock(v.forBlock)
-			}
+// appendFunctionBlocks appends the basic-blocks of the function declaration or literal to basicBlocks,
+// followed by the basic-blocks of the function literals in it, named like name.func1 in a declaration
+// and name.1 in a literal.
+func appendFunctionBlocks(basicBlocks []*BasicBlock, fileSet *token.FileSet, name string, function ast.Node,
+	body *ast.BlockStmt, imports map[string]string) []*BasicBlock {
+	basicBlocks = append(basicBlocks, functionBlocks(fileSet, name, function, body, imports, nil)...)
+	for index, funcLit := range funcLits(body) {
+		funcLitName := fmt.Sprintf("%s.func%d", name, index+1)
+		if _, ok := function.(*ast.FuncLit); ok {
+			funcLitName = fmt.Sprintf("%s.%d", name, index+1)
+		}
+		basicBlocks = appendFunctionBlocks(basicBlocks, fileSet, funcLitName, funcLit, funcLit.Body, imports)
+	}
+	return basicBlocks
+}
 
-			if v.switchBlock != nil {
-				v.switchBlock.AddSuccessorBlock(caseClause)
-			}
+// functionName returns the name of the function declaration as the go toolchain names it, methods
+// are qualified by their receiver type like (*T).M or T.M, and generic types like T[...].M.
+func functionName(funcDecl *ast.FuncDecl) string {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+		return funcDecl.Name.Name
+	}
+	recvType := ast.Unparen(funcDecl.Recv.List[0].Type)
+	star, pointer := recvType.(*ast.StarExpr)
+	if pointer {
+		recvType = ast.Unparen(star.X)
+	}
+	typeName := "?"
+	switch t := recvType.(type) {
+	case *ast.Ident:
+		typeName = t.Name
+	case *ast.IndexExpr:
+		if ident, ok := t.X.(*ast.Ident); ok {
+			typeName = ident.Name + "[...]"
+		}
+	case *ast.IndexListExpr:
+		if ident, ok := t.X.(*ast.Ident); ok {
+			typeName = ident.Name + "[...]"
+		}
+	}
+	if pointer {
+		return fmt.Sprintf("(*%s).%s", typeName, funcDecl.Name.Name)
+	}
+	return fmt.Sprintf("%s.%s", typeName, funcDecl.Name.Name)
+}
 
-			if v.returnBlock != nil {
-				caseClause.AddSuccessorBlock(v.returnBlock)
-			}
+// funcLits returns the function literals in body, not nested in another function literal, in source order.
+func funcLits(body *ast.BlockStmt) (funcLits []*ast.FuncLit) {
+	if body == nil {
+		return nil
+	}
+	ast.Inspect(body, func(node ast.Node) bool {
+		if funcLit, ok := node.(*ast.FuncLit); ok {
+			funcLits = append(funcLits, funcLit)
+			return false
+		}
+		return true
+	})
+	return funcLits
+}
 
-			tmpSwitchBlock := v.switchBlock
-			tmpReturnBLock := v.returnBlock
-			for _, s := range t.Body {
-				v.Visit(s)
-			}
-			v.switchBlock = tmpSwitchBlock
-			v.returnBlock = tmpReturnBLock
-
-			//TODO: Special case.
-			//TODO: Type is always CASE_CLAUSE type
-			if v.returnBlock != nil && caseClause.Type != RETURN_STMT && caseClause.Type != SWITCH_STATEMENT {
-				//TODO: This must be refactored more beautiful
-				containsForStatement := false
-				for _, b := range caseClause.GetSuccessorBlocks() {
-					if b.Type == FOR_STATEMENT {
-						containsForStatement = true
-					}
-				}
-				if !containsForStatement {
-					caseClause.AddSuccessorBlock(v.returnBlock)
-				}
-			}
+// fileImports returns the import paths of the file by the name the file refers to them.
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, importSpec := range file.Imports {
+		importPath, err := strconv.Unquote(importSpec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(importPath)
+		if importSpec.Name != nil {
+			name = importSpec.Name.Name
+		}
+		imports[name] = importPath
+	}
+	return imports
+}
 
-		case *ast.CommClause:
-			var caseClause *BasicBlock
-			if basicBlockType, s := GetBasicBlockTypeFromStmt(t.Body); basicBlockType != UNKNOWN {
-				caseClause = v.AddBasicBlock(basicBlockType, s.Pos())
-			} else {
-				caseClause = v.AddBasicBlock(COMM_CLAUSE, t.End())
-			}
+func GetBasicBlocksFromSourceCode(filePath string, srcFile []byte) ([]*BasicBlock, error) {
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, filePath, srcFile, parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		return nil, fmt.Errorf("Parse error: %s", err)
+	}
+	return GetBasicBlocksFromFile(fileSet, file), nil
+}
 
-			if v.forBlock != nil {
-				caseClause.AddSuccessorBlock(v.forBlock)
-			}
+// GetBasicBlocksFromFile returns the basic-blocks of the functions and methods in the already parsed
+// file, each function starting with a FUNCTION_ENTRY block and followed by the function literals in it,
+// which only are a statement creating the closure in the enclosing function. Positions are resolved through fileSet.
+// The syntax tree is only read, so it can be shared.
+func GetBasicBlocksFromFile(fileSet *token.FileSet, file *ast.File) []*BasicBlock {
+	var basicBlocks []*BasicBlock
+	imports := fileImports(file)
+	for _, decl := range file.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+			basicBlocks = appendFunctionBlocks(basicBlocks, fileSet, functionName(funcDecl), funcDecl, funcDecl.Body, imports)
+		}
+	}
+	for index, basicBlock := range basicBlocks {
+		basicBlock.Number = index //Set basic-block number.
+	}
+	return basicBlocks
+}
 
-			if v.switchBlock != nil {
-				v.switchBlock.AddSuccessorBlock(caseClause)
-			}
+// GetBasicBlocksFromFunction returns the basic-blocks of the *ast.FuncDecl or *ast.FuncLit function, numbered
+// from 0 and starting with its FUNCTION_ENTRY block. Function literals in the function are only statements
+// creating the closure, and a function literal has no FunctionName. Called functions are resolved through
+// info, or by the package names as written if info is nil. Positions are resolved through fileSet.
+func GetBasicBlocksFromFunction(fileSet *token.FileSet, function ast.Node, info *types.Info) []*BasicBlock {
+	var basicBlocks []*BasicBlock
+	switch f := function.(type) {
+	case *ast.FuncDecl:
+		basicBlocks = functionBlocks(fileSet, functionName(f), f, f.Body, nil, info)
+	case *ast.FuncLit:
+		basicBlocks = functionBlocks(fileSet, "", f, f.Body, nil, info)
+	default:
+		return nil
+	}
+	for index, basicBlock := range basicBlocks {
+		basicBlock.Number = index
+	}
+	return basicBlocks
+}
 
-			if v.returnBlock != nil {
-				caseClause.AddSuccessorBlock(v.returnBlock)
-			}
+func PrintBasicBlocks(basicBlocks []*BasicBlock) {
+	for _, bb := range basicBlocks {
+		log.Printf("%d) %s (Lines: %d-%d)\n", bb.Number, bb.Type.String(), bb.StartLine, bb.EndLine)
 
-			tmpSwitchBlock := v.switchBlock
-			tmpReturnBLock := v.returnBlock
-			for _, s := range t.Body {
-				v.Visit(s)
-			}
-			v.switchBlock = tmpSwitchBlock
-			v.returnBlock = tmpReturnBLock
-
-			//TODO: Special case.
-			//TODO: Type is always CASE_CLAUSE type
-			if v.returnBlock != nil && caseClause.Type != RETURN_STMT && caseClause.Type != SWITCH_STATEMENT {
-				//TODO: This must be refactored more beautifully
-				containsForStatement := false
-				for _, b := range caseClause.GetSuccessorBlocks() {
-					if b.Type == FOR_STATEMENT {
-						containsForStatement = true
-					}
-				}
-				if !containsForStatement {
-					caseClause.AddSuccessorBlock(v.returnBlock)
-				}
-			}
+		for _, sBB := range bb.GetSuccessorBlocks() {
+			log.Printf("\t-> (%d) %s (Lines: %d-%d)\n", sBB.Number, sBB.Type.String(), sBB.StartLine, sBB.EndLine)
 		}
 	}
-	return v
 }
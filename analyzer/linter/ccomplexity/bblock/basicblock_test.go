@@ -5,6 +5,7 @@ package bblock_test
 
 import (
 	"fmt"
+	"go/ast"
 	"io/ioutil"
 	"testing"
 
@@ -52,21 +53,23 @@ func verifyBasicBlocks(expectedBasicBlocks []*bblock.BasicBlock, correctBasicBlo
 
 func TestEmptyFunctionBasicBlock(t *testing.T) {
 	filePath := "./testcode/_emptyfunction.go"
-	sourceFile, err := ioutil.ReadFile(filePath)
+	srcFile, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		t.Fatal(err)
 	}
-	expectedBasicBlocks, err := bblock.GetBasicBlocksFromSourceCode(filePath, sourceFile)
+	expectedBasicBlocks, err := bblock.GetBasicBlocksFromSourceCode(filePath, srcFile)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
 	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 6)
-	BB1 := bblock.NewBasicBlock(1, bblock.RETURN_STMT, 9)
 
-	BB0.AddSuccessorBlock(BB1)
+	BB0.AddSuccessorBlock(EXIT)
 
-	correctBasicBlocks := []*bblock.BasicBlock{BB0, BB1}
+	correctBasicBlocks := []*bblock.BasicBlock{
+		BB0,
+	}
 
 	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
 		t.Fatal(err)
@@ -75,21 +78,23 @@ func TestEmptyFunctionBasicBlock(t *testing.T) {
 
 func TestSingleBasicBlock(t *testing.T) {
 	filePath := "./testcode/_simple.go"
-	sourceFile, err := ioutil.ReadFile(filePath)
+	srcFile, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		t.Fatal(err)
 	}
-	expectedBasicBlocks, err := bblock.GetBasicBlocksFromSourceCode(filePath, sourceFile)
+	expectedBasicBlocks, err := bblock.GetBasicBlocksFromSourceCode(filePath, srcFile)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
-	BB1 := bblock.NewBasicBlock(1, bblock.RETURN_STMT, 11)
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 10)
 
-	BB0.AddSuccessorBlock(BB1)
+	BB0.AddSuccessorBlock(EXIT)
 
-	correctBasicBlocks := []*bblock.BasicBlock{BB0, BB1}
+	correctBasicBlocks := []*bblock.BasicBlock{
+		BB0,
+	}
 
 	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
 		t.Fatal(err)
@@ -107,16 +112,18 @@ func TestIfElseBasicBlock(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 11)
 	BB1 := bblock.NewBasicBlock(1, bblock.IF_CONDITION, 13)
-	BB2 := bblock.NewBasicBlock(2, bblock.ELSE_CONDITION, 16)
-	BB3 := bblock.NewBasicBlock(3, bblock.ELSE_BODY, 20)
-	BB4 := bblock.NewBasicBlock(4, bblock.RETURN_STMT, 24)
+	BB2 := bblock.NewBasicBlock(2, bblock.IF_BODY, 15)
+	BB3 := bblock.NewBasicBlock(3, bblock.ELSE_BODY, 19)
+	BB4 := bblock.NewBasicBlock(4, bblock.IF_DONE, 23)
 
 	BB0.AddSuccessorBlock(BB1)
 	BB1.AddSuccessorBlock(BB2, BB3)
 	BB2.AddSuccessorBlock(BB4)
 	BB3.AddSuccessorBlock(BB4)
+	BB4.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
 		BB0, BB1, BB2, BB3, BB4,
@@ -129,7 +136,7 @@ func TestIfElseBasicBlock(t *testing.T) {
 
 func TestIfElseWithReturn(t *testing.T) {
 	filePath := "./testcode/_ifelsereturn.go"
-	srcFile, err := ioutil.ReadFile("./testcode/_ifelsereturn.go")
+	srcFile, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -138,15 +145,18 @@ func TestIfElseWithReturn(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
 	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
 	BB1 := bblock.NewBasicBlock(1, bblock.IF_CONDITION, 10)
-	BB2 := bblock.NewBasicBlock(2, bblock.RETURN_STMT, 12)
-	BB3 := bblock.NewBasicBlock(3, bblock.ELSE_BODY, 15)
-	BB4 := bblock.NewBasicBlock(4, bblock.RETURN_STMT, 16)
+	BB2 := bblock.NewBasicBlock(2, bblock.IF_BODY, 12)
+	BB3 := bblock.NewBasicBlock(3, bblock.ELSE_BODY, 14)
+	BB4 := bblock.NewBasicBlock(4, bblock.IF_DONE, 16)
 
 	BB0.AddSuccessorBlock(BB1)
 	BB1.AddSuccessorBlock(BB2, BB3)
+	BB2.AddSuccessorBlock(EXIT)
 	BB3.AddSuccessorBlock(BB4)
+	BB4.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
 		BB0, BB1, BB2, BB3, BB4,
@@ -159,7 +169,7 @@ func TestIfElseWithReturn(t *testing.T) {
 
 func TestIfElseIfBasicBlock(t *testing.T) {
 	filePath := "./testcode/_ifelseif.go"
-	srcFile, err := ioutil.ReadFile("./testcode/_ifelseif.go")
+	srcFile, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -168,19 +178,25 @@ func TestIfElseIfBasicBlock(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 7)
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 9)
 	BB1 := bblock.NewBasicBlock(1, bblock.IF_CONDITION, 11)
-	BB2 := bblock.NewBasicBlock(2, bblock.ELSE_CONDITION, 13)
-	BB3 := bblock.NewBasicBlock(3, bblock.ELSE_BODY, 16)
-	BB4 := bblock.NewBasicBlock(4, bblock.RETURN_STMT, 23)
+	BB2 := bblock.NewBasicBlock(2, bblock.IF_BODY, 12)
+	BB3 := bblock.NewBasicBlock(3, bblock.IF_CONDITION, 13)
+	BB4 := bblock.NewBasicBlock(4, bblock.IF_BODY, 15)
+	BB5 := bblock.NewBasicBlock(5, bblock.IF_DONE, 16)
+	BB6 := bblock.NewBasicBlock(6, bblock.IF_DONE, 22)
 
 	BB0.AddSuccessorBlock(BB1)
 	BB1.AddSuccessorBlock(BB2, BB3)
-	BB2.AddSuccessorBlock(BB4)
-	BB3.AddSuccessorBlock(BB4)
+	BB2.AddSuccessorBlock(BB6)
+	BB3.AddSuccessorBlock(BB4, BB5)
+	BB4.AddSuccessorBlock(BB5)
+	BB5.AddSuccessorBlock(BB6)
+	BB6.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
-		BB0, BB1, BB2, BB3, BB4,
+		BB0, BB1, BB2, BB3, BB4, BB5, BB6,
 	}
 
 	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
@@ -199,25 +215,29 @@ func TestNestedIfElseBasicBlock(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
 	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 11)
 	BB1 := bblock.NewBasicBlock(1, bblock.IF_CONDITION, 14)
-	BB2 := bblock.NewBasicBlock(2, bblock.IF_CONDITION, 18)
-	BB3 := bblock.NewBasicBlock(3, bblock.ELSE_CONDITION, 21)
-	BB4 := bblock.NewBasicBlock(4, bblock.ELSE_BODY, 25)
-	BB5 := bblock.NewBasicBlock(5, bblock.ELSE_CONDITION, 26)
-	BB6 := bblock.NewBasicBlock(6, bblock.ELSE_BODY, 30)
-	BB7 := bblock.NewBasicBlock(7, bblock.RETURN_STMT, 34)
+	BB2 := bblock.NewBasicBlock(2, bblock.IF_BODY, 17)
+	BB3 := bblock.NewBasicBlock(3, bblock.IF_CONDITION, 18)
+	BB4 := bblock.NewBasicBlock(4, bblock.IF_BODY, 20)
+	BB5 := bblock.NewBasicBlock(5, bblock.ELSE_BODY, 24)
+	BB6 := bblock.NewBasicBlock(6, bblock.IF_DONE, 25)
+	BB7 := bblock.NewBasicBlock(7, bblock.ELSE_BODY, 29)
+	BB8 := bblock.NewBasicBlock(8, bblock.IF_DONE, 33)
 
 	BB0.AddSuccessorBlock(BB1)
-	BB1.AddSuccessorBlock(BB2, BB6)
-	BB2.AddSuccessorBlock(BB3, BB4)
-	BB3.AddSuccessorBlock(BB7)
-	BB4.AddSuccessorBlock(BB7)
-	BB5.AddSuccessorBlock(BB7)
-	BB6.AddSuccessorBlock(BB7)
+	BB1.AddSuccessorBlock(BB2, BB7)
+	BB2.AddSuccessorBlock(BB3)
+	BB3.AddSuccessorBlock(BB4, BB5)
+	BB4.AddSuccessorBlock(BB6)
+	BB5.AddSuccessorBlock(BB6)
+	BB6.AddSuccessorBlock(BB8)
+	BB7.AddSuccessorBlock(BB8)
+	BB8.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
-		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7,
+		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8,
 	}
 
 	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
@@ -236,17 +256,21 @@ func TestLooperBasicBlock(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 11)
 	BB1 := bblock.NewBasicBlock(1, bblock.FOR_STATEMENT, 11)
-	BB2 := bblock.NewBasicBlock(2, bblock.FOR_BODY, 14)
-	BB3 := bblock.NewBasicBlock(3, bblock.RETURN_STMT, 16)
+	BB2 := bblock.NewBasicBlock(2, bblock.FOR_BODY, 13)
+	BB3 := bblock.NewBasicBlock(3, bblock.FOR_POST, 11)
+	BB4 := bblock.NewBasicBlock(4, bblock.FOR_DONE, 15)
 
 	BB0.AddSuccessorBlock(BB1)
-	BB1.AddSuccessorBlock(BB2, BB3)
-	BB2.AddSuccessorBlock(BB1)
+	BB1.AddSuccessorBlock(BB2, BB4)
+	BB2.AddSuccessorBlock(BB3)
+	BB3.AddSuccessorBlock(BB1)
+	BB4.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
-		BB0, BB1, BB2, BB3,
+		BB0, BB1, BB2, BB3, BB4,
 	}
 
 	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
@@ -265,20 +289,22 @@ func TestSimpleSwitchBasicBlock(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 10)
 	BB1 := bblock.NewBasicBlock(1, bblock.SWITCH_STATEMENT, 12)
 	BB2 := bblock.NewBasicBlock(2, bblock.CASE_CLAUSE, 15)
 	BB3 := bblock.NewBasicBlock(3, bblock.CASE_CLAUSE, 17)
 	BB4 := bblock.NewBasicBlock(4, bblock.CASE_CLAUSE, 19)
 	BB5 := bblock.NewBasicBlock(5, bblock.CASE_CLAUSE, 21)
-	BB6 := bblock.NewBasicBlock(6, bblock.RETURN_STMT, 23)
+	BB6 := bblock.NewBasicBlock(6, bblock.SWITCH_DONE, 22)
 
 	BB0.AddSuccessorBlock(BB1)
-	BB1.AddSuccessorBlock(BB2, BB3, BB4, BB5, BB6)
+	BB1.AddSuccessorBlock(BB2, BB3, BB4, BB5)
 	BB2.AddSuccessorBlock(BB6)
 	BB3.AddSuccessorBlock(BB6)
 	BB4.AddSuccessorBlock(BB6)
 	BB5.AddSuccessorBlock(BB6)
+	BB6.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
 		BB0, BB1, BB2, BB3, BB4, BB5, BB6,
@@ -300,23 +326,26 @@ func TestSwitchBasicBlock(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 10)
 	BB1 := bblock.NewBasicBlock(1, bblock.SWITCH_STATEMENT, 12)
 	BB2 := bblock.NewBasicBlock(2, bblock.CASE_CLAUSE, 15)
 	BB3 := bblock.NewBasicBlock(3, bblock.CASE_CLAUSE, 18)
 	BB4 := bblock.NewBasicBlock(4, bblock.CASE_CLAUSE, 20)
 	BB5 := bblock.NewBasicBlock(5, bblock.CASE_CLAUSE, 22)
-	BB6 := bblock.NewBasicBlock(6, bblock.RETURN_STMT, 25)
+	BB6 := bblock.NewBasicBlock(6, bblock.CASE_CLAUSE, 25)
 	BB7 := bblock.NewBasicBlock(7, bblock.CASE_CLAUSE, 27)
-	BB8 := bblock.NewBasicBlock(8, bblock.RETURN_STMT, 29)
+	BB8 := bblock.NewBasicBlock(8, bblock.SWITCH_DONE, 28)
 
 	BB0.AddSuccessorBlock(BB1)
-	BB1.AddSuccessorBlock(BB2, BB3, BB4, BB5, BB6, BB7, BB8)
+	BB1.AddSuccessorBlock(BB2, BB3, BB4, BB5, BB6, BB7)
 	BB2.AddSuccessorBlock(BB8)
 	BB3.AddSuccessorBlock(BB8)
 	BB4.AddSuccessorBlock(BB8)
 	BB5.AddSuccessorBlock(BB8)
+	BB6.AddSuccessorBlock(EXIT)
 	BB7.AddSuccessorBlock(BB8)
+	BB8.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
 		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8,
@@ -338,33 +367,43 @@ func TestReturnSwitcherBasicBlock(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
-	BB1 := bblock.NewBasicBlock(1, bblock.RETURN_STMT, 12)
-	BB2 := bblock.NewBasicBlock(2, bblock.FUNCTION_ENTRY, 14)
-	BB3 := bblock.NewBasicBlock(3, bblock.SWITCH_STATEMENT, 16)
-	BB4 := bblock.NewBasicBlock(4, bblock.RETURN_STMT, 18)
-	BB5 := bblock.NewBasicBlock(5, bblock.RETURN_STMT, 20)
-	BB6 := bblock.NewBasicBlock(6, bblock.RETURN_STMT, 22)
-	BB7 := bblock.NewBasicBlock(7, bblock.RETURN_STMT, 24)
-	BB8 := bblock.NewBasicBlock(8, bblock.RETURN_STMT, 26)
-	BB9 := bblock.NewBasicBlock(9, bblock.RETURN_STMT, 28)
-	BB10 := bblock.NewBasicBlock(10, bblock.RETURN_STMT, 30)
-	BB11 := bblock.NewBasicBlock(11, bblock.RETURN_STMT, 32)
-	BB12 := bblock.NewBasicBlock(12, bblock.RETURN_STMT, 34)
-	BB13 := bblock.NewBasicBlock(13, bblock.RETURN_STMT, 36)
-	BB14 := bblock.NewBasicBlock(14, bblock.RETURN_STMT, 38)
-	BB15 := bblock.NewBasicBlock(15, bblock.RETURN_STMT, 40)
-	BB16 := bblock.NewBasicBlock(16, bblock.RETURN_STMT, 42)
-
-	// Function main.
-	BB0.AddSuccessorBlock(BB1)
-
-	// Function monthNumberToString.
-	BB2.AddSuccessorBlock(BB3)
-	BB3.AddSuccessorBlock(BB4, BB5, BB6, BB7, BB8, BB9, BB10, BB11, BB12, BB13, BB14, BB15, BB16)
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 11)
+	BB1 := bblock.NewBasicBlock(1, bblock.FUNCTION_ENTRY, 14)
+	BB2 := bblock.NewBasicBlock(2, bblock.SWITCH_STATEMENT, 16)
+	BB3 := bblock.NewBasicBlock(3, bblock.CASE_CLAUSE, 18)
+	BB4 := bblock.NewBasicBlock(4, bblock.CASE_CLAUSE, 20)
+	BB5 := bblock.NewBasicBlock(5, bblock.CASE_CLAUSE, 22)
+	BB6 := bblock.NewBasicBlock(6, bblock.CASE_CLAUSE, 24)
+	BB7 := bblock.NewBasicBlock(7, bblock.CASE_CLAUSE, 26)
+	BB8 := bblock.NewBasicBlock(8, bblock.CASE_CLAUSE, 28)
+	BB9 := bblock.NewBasicBlock(9, bblock.CASE_CLAUSE, 30)
+	BB10 := bblock.NewBasicBlock(10, bblock.CASE_CLAUSE, 32)
+	BB11 := bblock.NewBasicBlock(11, bblock.CASE_CLAUSE, 34)
+	BB12 := bblock.NewBasicBlock(12, bblock.CASE_CLAUSE, 36)
+	BB13 := bblock.NewBasicBlock(13, bblock.CASE_CLAUSE, 38)
+	BB14 := bblock.NewBasicBlock(14, bblock.CASE_CLAUSE, 40)
+	BB15 := bblock.NewBasicBlock(15, bblock.SWITCH_DONE, 42)
+
+	BB0.AddSuccessorBlock(EXIT)
+	BB1.AddSuccessorBlock(BB2)
+	BB2.AddSuccessorBlock(BB3, BB4, BB5, BB6, BB7, BB8, BB9, BB10, BB11, BB12, BB13, BB14, BB15)
+	BB3.AddSuccessorBlock(EXIT)
+	BB4.AddSuccessorBlock(EXIT)
+	BB5.AddSuccessorBlock(EXIT)
+	BB6.AddSuccessorBlock(EXIT)
+	BB7.AddSuccessorBlock(EXIT)
+	BB8.AddSuccessorBlock(EXIT)
+	BB9.AddSuccessorBlock(EXIT)
+	BB10.AddSuccessorBlock(EXIT)
+	BB11.AddSuccessorBlock(EXIT)
+	BB12.AddSuccessorBlock(EXIT)
+	BB13.AddSuccessorBlock(EXIT)
+	BB14.AddSuccessorBlock(EXIT)
+	BB15.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
-		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8, BB9, BB10, BB11, BB12, BB13, BB14, BB15, BB16,
+		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8, BB9, BB10, BB11, BB12, BB13, BB14, BB15,
 	}
 
 	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
@@ -383,33 +422,39 @@ func TestNestedSwitchBasicBlock(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 12)
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 15)
 	BB1 := bblock.NewBasicBlock(1, bblock.SWITCH_STATEMENT, 17)
 	BB2 := bblock.NewBasicBlock(2, bblock.CASE_CLAUSE, 20)
-	BB3 := bblock.NewBasicBlock(3, bblock.SWITCH_STATEMENT, 23)
-	BB4 := bblock.NewBasicBlock(4, bblock.CASE_CLAUSE, 26)
-	BB5 := bblock.NewBasicBlock(5, bblock.CASE_CLAUSE, 28)
-	BB6 := bblock.NewBasicBlock(6, bblock.CASE_CLAUSE, 30)
-	BB7 := bblock.NewBasicBlock(7, bblock.CASE_CLAUSE, 33)
-	BB8 := bblock.NewBasicBlock(8, bblock.CASE_CLAUSE, 35)
-	BB9 := bblock.NewBasicBlock(9, bblock.CASE_CLAUSE, 37)
-	BB10 := bblock.NewBasicBlock(10, bblock.RETURN_STMT, 39)
+	BB3 := bblock.NewBasicBlock(3, bblock.CASE_CLAUSE, 22)
+	BB4 := bblock.NewBasicBlock(4, bblock.SWITCH_STATEMENT, 23)
+	BB5 := bblock.NewBasicBlock(5, bblock.CASE_CLAUSE, 26)
+	BB6 := bblock.NewBasicBlock(6, bblock.CASE_CLAUSE, 28)
+	BB7 := bblock.NewBasicBlock(7, bblock.CASE_CLAUSE, 30)
+	BB8 := bblock.NewBasicBlock(8, bblock.SWITCH_DONE, 31)
+	BB9 := bblock.NewBasicBlock(9, bblock.CASE_CLAUSE, 33)
+	BB10 := bblock.NewBasicBlock(10, bblock.CASE_CLAUSE, 35)
+	BB11 := bblock.NewBasicBlock(11, bblock.CASE_CLAUSE, 37)
+	BB12 := bblock.NewBasicBlock(12, bblock.SWITCH_DONE, 38)
+
+	BB0.AddSuccessorBlock(BB1)
+	BB1.AddSuccessorBlock(BB2, BB3, BB9, BB10, BB11)
+	BB2.AddSuccessorBlock(BB12)
+	BB3.AddSuccessorBlock(BB4)
+	BB4.AddSuccessorBlock(BB5, BB6, BB7)
+	BB5.AddSuccessorBlock(BB8)
+	BB6.AddSuccessorBlock(BB8)
+	BB7.AddSuccessorBlock(BB8)
+	BB8.AddSuccessorBlock(BB12)
+	BB9.AddSuccessorBlock(BB12)
+	BB10.AddSuccessorBlock(BB12)
+	BB11.AddSuccessorBlock(BB12)
+	BB12.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
-		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8, BB9, BB10,
+		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8, BB9, BB10, BB11, BB12,
 	}
 
-	BB0.AddSuccessorBlock(BB1)
-	BB1.AddSuccessorBlock(BB2, BB3, BB7, BB8, BB9, BB10)
-	BB2.AddSuccessorBlock(BB10)
-	BB3.AddSuccessorBlock(BB4, BB5, BB6, BB10)
-	BB4.AddSuccessorBlock(BB10)
-	BB5.AddSuccessorBlock(BB10)
-	BB6.AddSuccessorBlock(BB10)
-	BB7.AddSuccessorBlock(BB10)
-	BB8.AddSuccessorBlock(BB10)
-	BB9.AddSuccessorBlock(BB10)
-
 	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
 		t.Fatal(err)
 	}
@@ -423,31 +468,27 @@ func TestTypeSwitchBasicBlock(t *testing.T) {
 	}
 	expectedBasicBlocks, err := bblock.GetBasicBlocksFromSourceCode(filePath, srcFile)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 12)
 	BB1 := bblock.NewBasicBlock(1, bblock.SWITCH_STATEMENT, 14)
 	BB2 := bblock.NewBasicBlock(2, bblock.CASE_CLAUSE, 17)
 	BB3 := bblock.NewBasicBlock(3, bblock.CASE_CLAUSE, 19)
 	BB4 := bblock.NewBasicBlock(4, bblock.CASE_CLAUSE, 21)
 	BB5 := bblock.NewBasicBlock(5, bblock.CASE_CLAUSE, 23)
 	BB6 := bblock.NewBasicBlock(6, bblock.CASE_CLAUSE, 25)
-	BB7 := bblock.NewBasicBlock(7, bblock.RETURN_STMT, 28)
+	BB7 := bblock.NewBasicBlock(7, bblock.SWITCH_DONE, 27)
 
 	BB0.AddSuccessorBlock(BB1)
-
-	BB1.AddSuccessorBlock(BB2)
-	BB1.AddSuccessorBlock(BB3)
-	BB1.AddSuccessorBlock(BB4)
-	BB1.AddSuccessorBlock(BB5)
-	BB1.AddSuccessorBlock(BB6)
-
+	BB1.AddSuccessorBlock(BB2, BB3, BB4, BB5, BB6)
 	BB2.AddSuccessorBlock(BB7)
 	BB3.AddSuccessorBlock(BB7)
 	BB4.AddSuccessorBlock(BB7)
 	BB5.AddSuccessorBlock(BB7)
 	BB6.AddSuccessorBlock(BB7)
+	BB7.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
 		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7,
@@ -466,24 +507,29 @@ func TestSimpleLooperSwitch(t *testing.T) {
 	}
 	expectedBasicBlocks, err := bblock.GetBasicBlocksFromSourceCode(filePath, srcFile)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 11)
 	BB1 := bblock.NewBasicBlock(1, bblock.FOR_STATEMENT, 11)
-	BB2 := bblock.NewBasicBlock(2, bblock.SWITCH_STATEMENT, 13)
-	BB3 := bblock.NewBasicBlock(3, bblock.CASE_CLAUSE, 15)
-	BB4 := bblock.NewBasicBlock(4, bblock.CASE_CLAUSE, 17)
-	BB5 := bblock.NewBasicBlock(5, bblock.RETURN_STMT, 20)
+	BB2 := bblock.NewBasicBlock(2, bblock.FOR_BODY, 11)
+	BB3 := bblock.NewBasicBlock(3, bblock.SWITCH_STATEMENT, 13)
+	BB4 := bblock.NewBasicBlock(4, bblock.CASE_CLAUSE, 15)
+	BB5 := bblock.NewBasicBlock(5, bblock.CASE_CLAUSE, 17)
+	BB6 := bblock.NewBasicBlock(6, bblock.SWITCH_DONE, 18)
+	BB7 := bblock.NewBasicBlock(7, bblock.FOR_POST, 11)
 
 	BB0.AddSuccessorBlock(BB1)
-	BB1.AddSuccessorBlock(BB2, BB5)
-	BB2.AddSuccessorBlock(BB1, BB3, BB4)
-	BB3.AddSuccessorBlock(BB1)
-	BB4.AddSuccessorBlock(BB1)
+	BB1.AddSuccessorBlock(BB2)
+	BB2.AddSuccessorBlock(BB3)
+	BB3.AddSuccessorBlock(BB4, BB5, BB6)
+	BB4.AddSuccessorBlock(BB6)
+	BB5.AddSuccessorBlock(BB6)
+	BB6.AddSuccessorBlock(BB7)
+	BB7.AddSuccessorBlock(BB1)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
-		BB0, BB1, BB2, BB3, BB4, BB5,
+		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7,
 	}
 
 	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
@@ -502,22 +548,29 @@ func TestSelectBasicBlock(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 12)
-	BB1 := bblock.NewBasicBlock(1, bblock.GO_STATEMENT, 16)
-	BB2 := bblock.NewBasicBlock(2, bblock.FOR_STATEMENT, 24)
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 24)
+	BB1 := bblock.NewBasicBlock(1, bblock.FOR_STATEMENT, 24)
+	BB2 := bblock.NewBasicBlock(2, bblock.FOR_BODY, 24)
 	BB3 := bblock.NewBasicBlock(3, bblock.SELECT_STATEMENT, 26)
 	BB4 := bblock.NewBasicBlock(4, bblock.COMM_CLAUSE, 28)
-	BB5 := bblock.NewBasicBlock(5, bblock.RETURN_STMT, 31)
-	BB6 := bblock.NewBasicBlock(6, bblock.RETURN_STMT, 34)
+	BB5 := bblock.NewBasicBlock(5, bblock.COMM_CLAUSE, 31)
+	BB6 := bblock.NewBasicBlock(6, bblock.SELECT_DONE, 32)
+	BB7 := bblock.NewBasicBlock(7, bblock.FOR_POST, 24)
+	BB8 := bblock.NewBasicBlock(8, bblock.FUNCTION_ENTRY, 19) // Function literal started by the go statement.
 
 	BB0.AddSuccessorBlock(BB1)
 	BB1.AddSuccessorBlock(BB2)
-	BB2.AddSuccessorBlock(BB3, BB6)
-	BB3.AddSuccessorBlock(BB2, BB4, BB5)
-	BB4.AddSuccessorBlock(BB2)
+	BB2.AddSuccessorBlock(BB3)
+	BB3.AddSuccessorBlock(BB4, BB5)
+	BB4.AddSuccessorBlock(BB6)
+	BB5.AddSuccessorBlock(EXIT)
+	BB6.AddSuccessorBlock(BB7)
+	BB7.AddSuccessorBlock(BB1)
+	BB8.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
-		BB0, BB1, BB2, BB3, BB4, BB5, BB6,
+		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8,
 	}
 
 	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
@@ -527,7 +580,40 @@ func TestSelectBasicBlock(t *testing.T) {
 
 func TestGreatestCommonDivisor(t *testing.T) {
 	filePath := "./testcode/_gcd.go"
-	srcFile, err := ioutil.ReadFile("./testcode/_gcd.go")
+	srcFile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBasicBlocks, err := bblock.GetBasicBlocksFromSourceCode(filePath, srcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 11)
+	BB1 := bblock.NewBasicBlock(1, bblock.FUNCTION_ENTRY, 14)
+	BB2 := bblock.NewBasicBlock(2, bblock.FOR_STATEMENT, 16)
+	BB3 := bblock.NewBasicBlock(3, bblock.FOR_BODY, 18)
+	BB4 := bblock.NewBasicBlock(4, bblock.FOR_DONE, 20)
+
+	BB0.AddSuccessorBlock(EXIT)
+	BB1.AddSuccessorBlock(BB2)
+	BB2.AddSuccessorBlock(BB3, BB4)
+	BB3.AddSuccessorBlock(BB2)
+	BB4.AddSuccessorBlock(EXIT)
+
+	correctBasicBlocks := []*bblock.BasicBlock{
+		BB0, BB1, BB2, BB3, BB4,
+	}
+
+	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOneLinerBasicBlocks(t *testing.T) {
+	filePath := "./testcode/_oneliners.go"
+	srcFile, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -536,24 +622,336 @@ func TestGreatestCommonDivisor(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 6)
+	BB1 := bblock.NewBasicBlock(1, bblock.IF_CONDITION, 6)
+	BB2 := bblock.NewBasicBlock(2, bblock.IF_BODY, 6)
+	BB3 := bblock.NewBasicBlock(3, bblock.IF_DONE, 6)
+	BB4 := bblock.NewBasicBlock(4, bblock.FUNCTION_ENTRY, 8)
+	BB5 := bblock.NewBasicBlock(5, bblock.RANGE_STATEMENT, 8)
+	BB6 := bblock.NewBasicBlock(6, bblock.RANGE_BODY, 8)
+	BB7 := bblock.NewBasicBlock(7, bblock.RANGE_DONE, 8)
+
+	BB0.AddSuccessorBlock(BB1)
+	BB1.AddSuccessorBlock(BB2, BB3)
+	BB2.AddSuccessorBlock(EXIT)
+	BB3.AddSuccessorBlock(EXIT)
+	BB4.AddSuccessorBlock(BB5)
+	BB5.AddSuccessorBlock(BB6, BB7)
+	BB6.AddSuccessorBlock(BB5)
+	BB7.AddSuccessorBlock(EXIT)
+
+	correctBasicBlocks := []*bblock.BasicBlock{
+		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7,
+	}
+
+	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both returns of abs are on line 6, each in its own block.
+	for _, basicBlock := range expectedBasicBlocks[2:4] {
+		if len(basicBlock.Stmts) != 1 {
+			t.Fatalf("Basic block nr. %d should hold 1 statement, not %d!", basicBlock.Number, len(basicBlock.Stmts))
+		}
+		if _, ok := basicBlock.Stmts[0].(*ast.ReturnStmt); !ok || basicBlock.Start != basicBlock.Stmts[0].Pos() {
+			t.Errorf("Basic block nr. %d should start with its return statement!", basicBlock.Number)
+		}
+	}
+}
+
+func TestFallthroughBasicBlock(t *testing.T) {
+	filePath := "./testcode/_fallthrough.go"
+	srcFile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBasicBlocks, err := bblock.GetBasicBlocksFromSourceCode(filePath, srcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
 	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
-	BB1 := bblock.NewBasicBlock(1, bblock.RETURN_STMT, 12)
-	BB2 := bblock.NewBasicBlock(2, bblock.FUNCTION_ENTRY, 14)
-	BB3 := bblock.NewBasicBlock(3, bblock.FOR_STATEMENT, 16)
-	BB4 := bblock.NewBasicBlock(4, bblock.FOR_BODY, 19)
-	BB5 := bblock.NewBasicBlock(5, bblock.RETURN_STMT, 20)
+	BB1 := bblock.NewBasicBlock(1, bblock.SWITCH_STATEMENT, 10)
+	BB2 := bblock.NewBasicBlock(2, bblock.CASE_CLAUSE, 13)
+	BB3 := bblock.NewBasicBlock(3, bblock.CASE_CLAUSE, 16)
+	BB4 := bblock.NewBasicBlock(4, bblock.CASE_CLAUSE, 19)
+	BB5 := bblock.NewBasicBlock(5, bblock.CASE_CLAUSE, 21)
+	BB6 := bblock.NewBasicBlock(6, bblock.CASE_CLAUSE, 23)
+	BB7 := bblock.NewBasicBlock(7, bblock.CASE_CLAUSE, 26)
+	BB8 := bblock.NewBasicBlock(8, bblock.CASE_CLAUSE, 29)
+	BB9 := bblock.NewBasicBlock(9, bblock.CASE_CLAUSE, 31)
+	BB10 := bblock.NewBasicBlock(10, bblock.SWITCH_DONE, 33)
 
 	BB0.AddSuccessorBlock(BB1)
+	BB1.AddSuccessorBlock(BB2, BB3, BB4, BB5, BB6, BB7, BB8, BB9)
+	BB2.AddSuccessorBlock(BB10)
+	BB3.AddSuccessorBlock(BB4) // Fallthrough.
+	BB4.AddSuccessorBlock(BB5) // Fallthrough.
+	BB5.AddSuccessorBlock(BB10)
+	BB6.AddSuccessorBlock(BB10)
+	BB7.AddSuccessorBlock(BB8) // Fallthrough.
+	BB8.AddSuccessorBlock(BB9) // Fallthrough.
+	BB9.AddSuccessorBlock(BB10)
+	BB10.AddSuccessorBlock(EXIT)
+
+	correctBasicBlocks := []*bblock.BasicBlock{
+		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8, BB9, BB10,
+	}
+
+	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBranchesBasicBlock(t *testing.T) {
+	filePath := "./testcode/_branches.go"
+	srcFile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBasicBlocks, err := bblock.GetBasicBlocksFromSourceCode(filePath, srcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 9)
+	BB1 := bblock.NewBasicBlock(1, bblock.LABEL, 11) // retry:
+	BB2 := bblock.NewBasicBlock(2, bblock.LABEL, 13) // outer:
+	BB3 := bblock.NewBasicBlock(3, bblock.FOR_STATEMENT, 13)
+	BB4 := bblock.NewBasicBlock(4, bblock.FOR_BODY, 14)
+	BB5 := bblock.NewBasicBlock(5, bblock.FOR_STATEMENT, 14)
+	BB6 := bblock.NewBasicBlock(6, bblock.FOR_BODY, 14)
+	BB7 := bblock.NewBasicBlock(7, bblock.SWITCH_STATEMENT, 15)
+	BB8 := bblock.NewBasicBlock(8, bblock.CASE_CLAUSE, 17)   // continue outer
+	BB9 := bblock.NewBasicBlock(9, bblock.CASE_CLAUSE, 19)   // break outer
+	BB10 := bblock.NewBasicBlock(10, bblock.CASE_CLAUSE, 21) // break
+	BB11 := bblock.NewBasicBlock(11, bblock.SWITCH_DONE, 23)
+	BB12 := bblock.NewBasicBlock(12, bblock.FOR_POST, 14)
+	BB13 := bblock.NewBasicBlock(13, bblock.FOR_DONE, 24)
+	BB14 := bblock.NewBasicBlock(14, bblock.FOR_POST, 13)
+	BB15 := bblock.NewBasicBlock(15, bblock.FOR_DONE, 25)
+	BB16 := bblock.NewBasicBlock(16, bblock.IF_CONDITION, 26)
+	BB17 := bblock.NewBasicBlock(17, bblock.IF_BODY, 27) // goto retry
+	BB18 := bblock.NewBasicBlock(18, bblock.IF_DONE, 28)
+
+	BB0.AddSuccessorBlock(BB1)
+	BB1.AddSuccessorBlock(BB2)
 	BB2.AddSuccessorBlock(BB3)
-	BB3.AddSuccessorBlock(BB4)
-	BB3.AddSuccessorBlock(BB5)
+	BB3.AddSuccessorBlock(BB4, BB15)
+	BB4.AddSuccessorBlock(BB5)
+	BB5.AddSuccessorBlock(BB6, BB13)
+	BB6.AddSuccessorBlock(BB7)
+	BB7.AddSuccessorBlock(BB8, BB9, BB10, BB11)
+	BB8.AddSuccessorBlock(BB14)
+	BB9.AddSuccessorBlock(BB15)
+	BB10.AddSuccessorBlock(BB11)
+	BB11.AddSuccessorBlock(BB12)
+	BB12.AddSuccessorBlock(BB5)
+	BB13.AddSuccessorBlock(BB14)
+	BB14.AddSuccessorBlock(BB3)
+	BB15.AddSuccessorBlock(BB16)
+	BB16.AddSuccessorBlock(BB17, BB18)
+	BB17.AddSuccessorBlock(BB1)
+	BB18.AddSuccessorBlock(EXIT)
+
+	correctBasicBlocks := []*bblock.BasicBlock{
+		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8, BB9, BB10, BB11, BB12, BB13, BB14, BB15, BB16, BB17, BB18,
+	}
+
+	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeferPanicBasicBlock(t *testing.T) {
+	filePath := "./testcode/_defer.go"
+	srcFile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBasicBlocks, err := bblock.GetBasicBlocksFromSourceCode(filePath, srcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	NO_RETURN := bblock.NewBasicBlock(-1, bblock.NO_RETURN, 0)
+
+	// Function 'closeAll'
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 13)
+	BB1 := bblock.NewBasicBlock(1, bblock.RANGE_STATEMENT, 14)
+	BB2 := bblock.NewBasicBlock(2, bblock.RANGE_BODY, 14)
+	BB3 := bblock.NewBasicBlock(3, bblock.IF_CONDITION, 15)
+	BB4 := bblock.NewBasicBlock(4, bblock.IF_BODY, 16) // panic
+	BB5 := bblock.NewBasicBlock(5, bblock.IF_DONE, 17)
+	BB6 := bblock.NewBasicBlock(6, bblock.RANGE_DONE, 18)
+	BB7 := bblock.NewBasicBlock(7, bblock.DEFERRED_CALLS, 19) // Panicking.
+	BB8 := bblock.NewBasicBlock(8, bblock.DEFERRED_CALLS, 19) // Returning.
+
+	// Function 'safeDivide'
+	BB9 := bblock.NewBasicBlock(9, bblock.FUNCTION_ENTRY, 26)
+	BB10 := bblock.NewBasicBlock(10, bblock.IF_CONDITION, 27)
+	BB11 := bblock.NewBasicBlock(11, bblock.IF_BODY, 28) // panic
+	BB12 := bblock.NewBasicBlock(12, bblock.IF_DONE, 30)
+	BB13 := bblock.NewBasicBlock(13, bblock.DEFERRED_CALLS, 31) // Panicking, recovers.
+	BB14 := bblock.NewBasicBlock(14, bblock.DEFERRED_CALLS, 31) // Returning.
+
+	// Function 'safeDivide.func1'
+	BB15 := bblock.NewBasicBlock(15, bblock.FUNCTION_ENTRY, 22)
+	BB16 := bblock.NewBasicBlock(16, bblock.IF_CONDITION, 23)
+	BB17 := bblock.NewBasicBlock(17, bblock.IF_BODY, 24)
+	BB18 := bblock.NewBasicBlock(18, bblock.IF_DONE, 25)
+
+	// Function 'mustOpen'
+	BB19 := bblock.NewBasicBlock(19, bblock.FUNCTION_ENTRY, 33)
+	BB20 := bblock.NewBasicBlock(20, bblock.IF_CONDITION, 34)
+	BB21 := bblock.NewBasicBlock(21, bblock.IF_BODY, 35) // log.Fatal
+	BB22 := bblock.NewBasicBlock(22, bblock.UNREACHABLE, 36)
+	BB23 := bblock.NewBasicBlock(23, bblock.IF_DONE, 37)
+	BB24 := bblock.NewBasicBlock(24, bblock.IF_CONDITION, 38)
+	BB25 := bblock.NewBasicBlock(25, bblock.IF_BODY, 39) // os.Exit, imported as xos.
+	BB26 := bblock.NewBasicBlock(26, bblock.IF_DONE, 40)
+
+	// Function 'readConfig'
+	BB27 := bblock.NewBasicBlock(27, bblock.FUNCTION_ENTRY, 43)
+	BB28 := bblock.NewBasicBlock(28, bblock.IF_CONDITION, 44)
+	BB29 := bblock.NewBasicBlock(29, bblock.IF_BODY, 45) // Returns before the defer statement.
+	BB30 := bblock.NewBasicBlock(30, bblock.IF_DONE, 47)
+	BB31 := bblock.NewBasicBlock(31, bblock.IF_CONDITION, 48)
+	BB32 := bblock.NewBasicBlock(32, bblock.IF_BODY, 49) // Returns before the defer statement.
+	BB33 := bblock.NewBasicBlock(33, bblock.IF_DONE, 51)
+	BB34 := bblock.NewBasicBlock(34, bblock.IF_CONDITION, 52)
+	BB35 := bblock.NewBasicBlock(35, bblock.IF_BODY, 53) // Returns after the defer statement.
+	BB36 := bblock.NewBasicBlock(36, bblock.IF_DONE, 55)
+	BB37 := bblock.NewBasicBlock(37, bblock.DEFERRED_CALLS, 56)
+
+	BB0.AddSuccessorBlock(BB1)
+	BB1.AddSuccessorBlock(BB2, BB6)
+	BB2.AddSuccessorBlock(BB3)
+	BB3.AddSuccessorBlock(BB4, BB5)
+	BB4.AddSuccessorBlock(BB7)
+	BB5.AddSuccessorBlock(BB1)
+	BB6.AddSuccessorBlock(BB8)
+	BB7.AddSuccessorBlock(NO_RETURN)
+	BB8.AddSuccessorBlock(EXIT)
+
+	BB9.AddSuccessorBlock(BB10)
+	BB10.AddSuccessorBlock(BB11, BB12)
+	BB11.AddSuccessorBlock(BB13)
+	BB12.AddSuccessorBlock(BB14)
+	BB13.AddSuccessorBlock(NO_RETURN, EXIT)
+	BB14.AddSuccessorBlock(EXIT)
+
+	BB15.AddSuccessorBlock(BB16)
+	BB16.AddSuccessorBlock(BB17, BB18)
+	BB17.AddSuccessorBlock(BB18)
+	BB18.AddSuccessorBlock(EXIT)
+
+	BB19.AddSuccessorBlock(BB20)
+	BB20.AddSuccessorBlock(BB21, BB23)
+	BB21.AddSuccessorBlock(NO_RETURN)
+	BB22.AddSuccessorBlock(BB23)
+	BB23.AddSuccessorBlock(BB24)
+	BB24.AddSuccessorBlock(BB25, BB26)
+	BB25.AddSuccessorBlock(NO_RETURN)
+	BB26.AddSuccessorBlock(EXIT)
+
+	BB27.AddSuccessorBlock(BB28)
+	BB28.AddSuccessorBlock(BB29, BB30)
+	BB29.AddSuccessorBlock(EXIT)
+	BB30.AddSuccessorBlock(BB31)
+	BB31.AddSuccessorBlock(BB32, BB33)
+	BB32.AddSuccessorBlock(EXIT)
+	BB33.AddSuccessorBlock(BB34)
+	BB34.AddSuccessorBlock(BB35, BB36)
+	BB35.AddSuccessorBlock(BB37)
+	BB36.AddSuccessorBlock(BB37)
+	BB37.AddSuccessorBlock(EXIT)
+
+	correctBasicBlocks := []*bblock.BasicBlock{
+		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8, BB9, BB10, BB11, BB12, BB13, BB14, BB15, BB16, BB17, BB18,
+		BB19, BB20, BB21, BB22, BB23, BB24, BB25, BB26, BB27, BB28, BB29, BB30, BB31, BB32, BB33, BB34, BB35, BB36,
+		BB37,
+	}
+
+	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the deferred function of safeDivide recovers.
+	for _, basicBlock := range expectedBasicBlocks {
+		recovers := basicBlock.Number == 13 || basicBlock.Number == 14
+		if basicBlock.Recovers != recovers {
+			t.Errorf("Basic block nr. %d should have Recovers %t, not %t!", basicBlock.Number, recovers, basicBlock.Recovers)
+		}
+	}
+	// Only the defer statement registered before returning runs.
+	if deferred := expectedBasicBlocks[37]; len(deferred.Stmts) != 1 {
+		t.Errorf("Basic block nr. 37 should run one deferred call, not %d!", len(deferred.Stmts))
+	}
+	noReturn := expectedBasicBlocks[7].GetSuccessorBlocks()[0]
+	if len(noReturn.GetSuccessorBlocks()) != 1 || noReturn.GetSuccessorBlocks()[0].Type != bblock.EXIT {
+		t.Errorf("%s should be followed by Exit!", noReturn)
+	}
+}
+
+func TestClosureBasicBlock(t *testing.T) {
+	filePath := "./testcode/_closures.go"
+	srcFile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBasicBlocks, err := bblock.GetBasicBlocksFromSourceCode(filePath, srcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+
+	// Function 'main', only creating the closures.
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 28)
+
+	// Function 'main.func1', the less function of sort.Slice.
+	BB1 := bblock.NewBasicBlock(1, bblock.FUNCTION_ENTRY, 14)
+
+	// Function 'main.func2', started by the go statement.
+	BB2 := bblock.NewBasicBlock(2, bblock.FUNCTION_ENTRY, 17)
+	BB3 := bblock.NewBasicBlock(3, bblock.RANGE_STATEMENT, 18)
+	BB4 := bblock.NewBasicBlock(4, bblock.RANGE_BODY, 24)
+	BB5 := bblock.NewBasicBlock(5, bblock.RANGE_DONE, 26)
+
+	// Function 'main.func2.1'.
+	BB6 := bblock.NewBasicBlock(6, bblock.FUNCTION_ENTRY, 19)
+	BB7 := bblock.NewBasicBlock(7, bblock.IF_CONDITION, 20)
+	BB8 := bblock.NewBasicBlock(8, bblock.IF_BODY, 21)
+	BB9 := bblock.NewBasicBlock(9, bblock.IF_DONE, 22)
+
+	BB0.AddSuccessorBlock(EXIT)
+	BB1.AddSuccessorBlock(EXIT)
+	BB2.AddSuccessorBlock(BB3)
+	BB3.AddSuccessorBlock(BB4, BB5)
 	BB4.AddSuccessorBlock(BB3)
+	BB5.AddSuccessorBlock(EXIT)
+	BB6.AddSuccessorBlock(BB7)
+	BB7.AddSuccessorBlock(BB8, BB9)
+	BB8.AddSuccessorBlock(BB9)
+	BB9.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{
-		BB0, BB1, BB2, BB3, BB4, BB5,
+		BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8, BB9,
 	}
 
 	if err := verifyBasicBlocks(expectedBasicBlocks, correctBasicBlocks); err != nil {
 		t.Fatal(err)
 	}
+
+	for number, name := range map[int]string{0: "main", 1: "main.func1", 2: "main.func2", 6: "main.func2.1"} {
+		if expectedBasicBlocks[number].FunctionName != name {
+			t.Errorf("Basic block nr. %d should start function %s, not %s!", number, name,
+				expectedBasicBlocks[number].FunctionName)
+		}
+	}
 }
@@ -0,0 +1,29 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package main
+
+import "fmt"
+
+func main() {
+	i := 0
+retry:
+	i++
+outer:
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			switch {
+			case x == y:
+				continue outer
+			case x > 5:
+				break outer
+			case y > 5:
+				break
+			}
+			fmt.Println(x, y)
+		}
+	}
+	if i < 3 {
+		goto retry
+	}
+}
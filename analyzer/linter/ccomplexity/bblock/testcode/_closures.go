@@ -0,0 +1,29 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+func main() {
+	numbers := []int{3, 1, 2}
+	sort.Slice(numbers, func(i, j int) bool {
+		return numbers[i] < numbers[j]
+	})
+	done := make(chan bool)
+	go func() {
+		for _, number := range numbers {
+			print := func() {
+				if number > 1 {
+					fmt.Println(number)
+				}
+			}
+			print()
+		}
+		done <- true
+	}()
+	<-done
+}
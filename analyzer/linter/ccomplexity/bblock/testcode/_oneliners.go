@@ -0,0 +1,8 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package main
+
+func abs(x int) int { if x < 0 { return -x }; return x }
+
+func sum(xs []int) (s int) { for _, x := range xs { s += x }; return }
@@ -9,18 +9,56 @@ import (
 	"github.com/chrisbbe/GoAnalysis/analyzer/globalvars"
 	"github.com/chrisbbe/GoAnalysis/analyzer/linter/ccomplexity/bblock"
 	"github.com/chrisbbe/GoAnalysis/analyzer/linter/ccomplexity/graph"
+	"go/ast"
+	"go/token"
+	"go/types"
 	"io"
 	"os"
 	"os/exec"
 	"time"
 )
 
+// ControlFlowGraph is the graph of the basic-blocks of a function, from the Start node through the
+// FUNCTION_ENTRY block, the Root, to the Exit node, with an edge from Exit back to Start.
 type ControlFlowGraph struct {
 	*graph.Graph
+	BasicBlocks []*bblock.BasicBlock // Basic-blocks of the function in source order, also those not in the graph.
 }
 
 func New() *ControlFlowGraph {
-	return &ControlFlowGraph{graph.NewGraph()}
+	return &ControlFlowGraph{Graph: graph.NewGraph()}
+}
+
+// Build returns the control-flow graph of the *ast.FuncDecl or *ast.FuncLit function, whose basic-blocks
+// hold the statements of the function. Function literals in the function are only statements creating the
+// closure, build their graphs from the *ast.FuncLit. Called functions are resolved through info, which may
+// be nil. Positions are resolved through fileSet.
+func Build(fileSet *token.FileSet, function ast.Node, info *types.Info) *ControlFlowGraph {
+	basicBlocks := bblock.GetBasicBlocksFromFunction(fileSet, function, info)
+	if len(basicBlocks) == 0 {
+		return nil
+	}
+	return getControlFlowGraph(basicBlocks)
+}
+
+// BasicBlock returns the basic-block executing stmt, nil if stmt is not a statement of the function. If, for,
+// range, switch and select statements are found in the block executing their header, defer statements in the
+// block deferring the call.
+func (controlFlowGraph *ControlFlowGraph) BasicBlock(stmt ast.Stmt) *bblock.BasicBlock {
+	for _, basicBlock := range controlFlowGraph.BasicBlocks {
+		for _, blockStmt := range basicBlock.Stmts {
+			if blockStmt == stmt {
+				return basicBlock
+			}
+		}
+	}
+	return nil
+}
+
+// IsReachable returns whether control can flow into the basic-block of the function.
+func (controlFlowGraph *ControlFlowGraph) IsReachable(basicBlock *bblock.BasicBlock) bool {
+	node := controlFlowGraph.Nodes[basicBlock.UID()]
+	return node != nil && node.Value == basicBlock
 }
 
 func (controlFlowGraph ControlFlowGraph) Draw(name string) error {
@@ -43,7 +81,7 @@ func (controlFlowGraph ControlFlowGraph) Draw(name string) error {
 	// Start writing the graph.
 	content.WriteString("digraph ControlFlowGraph {\n")
 	content.WriteString("\trankdir=TB;\n")
-	content.WriteString("\tnode [shape = doublecircle]; Start Exit;")
+	content.WriteString("\tnode [shape = doublecircle]; Start Exit NoReturn;")
 	content.WriteString("\tnode [shape = ellipse];")
 	for _, node := range controlFlowGraph.Nodes {
 		for _, outNode := range node.GetOutNodes() {
@@ -59,6 +97,16 @@ func (controlFlowGraph ControlFlowGraph) Draw(name string) error {
 	return cmd.Run()
 }
 
+// WriteSVG draws the control-flow graph as a SVG image to w, with the Start node on top.
+func (controlFlowGraph *ControlFlowGraph) WriteSVG(w io.Writer) error {
+	for _, node := range controlFlowGraph.Nodes {
+		if basicBlock, ok := node.Value.(*bblock.BasicBlock); ok && basicBlock.Type == bblock.START {
+			return controlFlowGraph.Graph.WriteSVG(w, node)
+		}
+	}
+	return controlFlowGraph.Graph.WriteSVG(w)
+}
+
 // GetControlFlowGraph generates the control flow graph for each function or
 // method found in the sequence of basic-blocks. Returning an array of control
 // flow graphs where each entry represents an function or method.
@@ -81,35 +129,29 @@ func GetControlFlowGraph(basicBlocks []*bblock.BasicBlock) (cfg []*ControlFlowGr
 	return
 }
 
-// getControlFlowGraph generates and returns the control-flow graph based on the array of basic blocks.
+// getControlFlowGraph generates and returns the control-flow graph of the basic blocks of a function,
+// starting with its FUNCTION_ENTRY block. Blocks control can not flow into are not part of the graph.
 func getControlFlowGraph(basicBlocks []*bblock.BasicBlock) *ControlFlowGraph {
 	controlFlowGraph := New()
-	var lastBlockAdded *bblock.BasicBlock
+	controlFlowGraph.BasicBlocks = append([]*bblock.BasicBlock(nil), basicBlocks...)
 
 	startNode := &graph.Node{Value: bblock.NewBasicBlock(-1, bblock.START, 0)}
 	exitNode := &graph.Node{Value: bblock.NewBasicBlock(-1, bblock.EXIT, 0)}
 
-	for _, basicBlock := range basicBlocks {
-		lastBlockAdded = basicBlock
-		basicBlockNode := &graph.Node{Value: basicBlock}
-		controlFlowGraph.InsertNode(basicBlockNode)
-
-		if basicBlock.Type == bblock.RETURN_STMT {
-			// RETURN_STMT terminates functior and methods, should therefor always have a edge to EXIT.
-			controlFlowGraph.InsertEdge(&graph.Node{Value: basicBlock}, exitNode)
-		}
-
+	controlFlowGraph.InsertNode(&graph.Node{Value: basicBlocks[0]})
+	visited := map[*bblock.BasicBlock]bool{basicBlocks[0]: true}
+	for queue := []*bblock.BasicBlock{basicBlocks[0]}; len(queue) > 0; queue = queue[1:] {
+		basicBlock := queue[0]
 		for _, successorBlock := range basicBlock.GetSuccessorBlocks() {
-			controlFlowGraph.InsertEdge(basicBlockNode, &graph.Node{Value: successorBlock})
-			lastBlockAdded = successorBlock
+			controlFlowGraph.InsertEdge(&graph.Node{Value: basicBlock}, &graph.Node{Value: successorBlock})
+			if !visited[successorBlock] && successorBlock.Type != bblock.EXIT {
+				visited[successorBlock] = true
+				queue = append(queue, successorBlock)
+			}
 		}
 	}
 
 	controlFlowGraph.InsertEdge(startNode, controlFlowGraph.Root)
-	if lastBlockAdded.Type != bblock.RETURN_STMT {
-		// Edge between RETURN_STMT and EXIT is already added higher up!
-		controlFlowGraph.InsertEdge(&graph.Node{Value: lastBlockAdded}, exitNode)
-	}
 	controlFlowGraph.InsertEdge(exitNode, startNode)
 
 	return controlFlowGraph
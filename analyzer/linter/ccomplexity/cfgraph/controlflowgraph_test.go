@@ -8,6 +8,10 @@ import (
 	"github.com/chrisbbe/GoAnalysis/analyzer/linter/ccomplexity/bblock"
 	"github.com/chrisbbe/GoAnalysis/analyzer/linter/ccomplexity/cfgraph"
 	"github.com/chrisbbe/GoAnalysis/analyzer/linter/ccomplexity/graph"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
 	"io/ioutil"
 	"testing"
 )
@@ -98,12 +102,11 @@ func TestSimpleControlFlowGraph(t *testing.T) {
 	START := bblock.NewBasicBlock(-1, bblock.START, 0)
 	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
-	BB1 := bblock.NewBasicBlock(1, bblock.RETURN_STMT, 11)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 10)
 
-	BB0.AddSuccessorBlock(BB1)
+	BB0.AddSuccessorBlock(EXIT)
 
-	correctBasicBlocks := []*bblock.BasicBlock{BB0, BB1}
+	correctBasicBlocks := []*bblock.BasicBlock{BB0}
 
 	//Test basic-blocks.
 	if err := VerifyBasicBlocks(basicBlocks, correctBasicBlocks); err != nil {
@@ -111,8 +114,7 @@ func TestSimpleControlFlowGraph(t *testing.T) {
 	}
 
 	correctGraph.InsertEdge(&graph.Node{Value: START}, &graph.Node{Value: BB0})
-	correctGraph.InsertEdge(&graph.Node{Value: BB0}, &graph.Node{Value: BB1})
-	correctGraph.InsertEdge(&graph.Node{Value: BB1}, &graph.Node{Value: EXIT})
+	correctGraph.InsertEdge(&graph.Node{Value: BB0}, &graph.Node{Value: EXIT})
 	correctGraph.InsertEdge(&graph.Node{Value: EXIT}, &graph.Node{Value: START})
 
 	if err := VerifyControlFlowGraphs(expectedGraph[0], correctGraph); err != nil {
@@ -136,16 +138,17 @@ func TestIfElseControlFlowGraph(t *testing.T) {
 	START := bblock.NewBasicBlock(-1, bblock.START, 0)
 	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 11)
 	BB1 := bblock.NewBasicBlock(1, bblock.IF_CONDITION, 13)
-	BB2 := bblock.NewBasicBlock(2, bblock.ELSE_CONDITION, 16)
-	BB3 := bblock.NewBasicBlock(3, bblock.ELSE_BODY, 20)
-	BB4 := bblock.NewBasicBlock(4, bblock.RETURN_STMT, 23)
+	BB2 := bblock.NewBasicBlock(2, bblock.IF_BODY, 15)
+	BB3 := bblock.NewBasicBlock(3, bblock.ELSE_BODY, 19)
+	BB4 := bblock.NewBasicBlock(4, bblock.IF_DONE, 22)
 
 	BB0.AddSuccessorBlock(BB1)
 	BB1.AddSuccessorBlock(BB2, BB3)
 	BB2.AddSuccessorBlock(BB4)
 	BB3.AddSuccessorBlock(BB4)
+	BB4.AddSuccessorBlock(EXIT)
 
 	correctBasicBlocks := []*bblock.BasicBlock{BB0, BB1, BB2, BB3, BB4}
 
@@ -184,16 +187,19 @@ func TestForLoopControlFlowGraph(t *testing.T) {
 	START := bblock.NewBasicBlock(-1, bblock.START, 0)
 	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
 
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 11)
 	BB1 := bblock.NewBasicBlock(1, bblock.FOR_STATEMENT, 11)
-	BB2 := bblock.NewBasicBlock(2, bblock.FOR_BODY, 14)
-	BB3 := bblock.NewBasicBlock(3, bblock.RETURN_STMT, 16)
+	BB2 := bblock.NewBasicBlock(2, bblock.FOR_BODY, 13)
+	BB3 := bblock.NewBasicBlock(3, bblock.FOR_POST, 11)
+	BB4 := bblock.NewBasicBlock(4, bblock.FOR_DONE, 15)
 
 	BB0.AddSuccessorBlock(BB1)
-	BB1.AddSuccessorBlock(BB2, BB3)
-	BB2.AddSuccessorBlock(BB1)
+	BB1.AddSuccessorBlock(BB2, BB4)
+	BB2.AddSuccessorBlock(BB3)
+	BB3.AddSuccessorBlock(BB1)
+	BB4.AddSuccessorBlock(EXIT)
 
-	correctBasicBlocks := []*bblock.BasicBlock{BB0, BB1, BB2, BB3}
+	correctBasicBlocks := []*bblock.BasicBlock{BB0, BB1, BB2, BB3, BB4}
 
 	//Test basic-blocks.
 	if err := VerifyBasicBlocks(basicBlocks, correctBasicBlocks); err != nil {
@@ -203,9 +209,10 @@ func TestForLoopControlFlowGraph(t *testing.T) {
 	correctGraph.InsertEdge(&graph.Node{Value: START}, &graph.Node{Value: BB0})
 	correctGraph.InsertEdge(&graph.Node{Value: BB0}, &graph.Node{Value: BB1})
 	correctGraph.InsertEdge(&graph.Node{Value: BB1}, &graph.Node{Value: BB2})
-	correctGraph.InsertEdge(&graph.Node{Value: BB2}, &graph.Node{Value: BB1})
-	correctGraph.InsertEdge(&graph.Node{Value: BB1}, &graph.Node{Value: BB3})
-	correctGraph.InsertEdge(&graph.Node{Value: BB3}, &graph.Node{Value: EXIT})
+	correctGraph.InsertEdge(&graph.Node{Value: BB1}, &graph.Node{Value: BB4})
+	correctGraph.InsertEdge(&graph.Node{Value: BB2}, &graph.Node{Value: BB3})
+	correctGraph.InsertEdge(&graph.Node{Value: BB3}, &graph.Node{Value: BB1})
+	correctGraph.InsertEdge(&graph.Node{Value: BB4}, &graph.Node{Value: EXIT})
 	correctGraph.InsertEdge(&graph.Node{Value: EXIT}, &graph.Node{Value: START})
 
 	//Test control-flow-graph.
@@ -234,23 +241,25 @@ func TestSwitchControlFlowGraph(t *testing.T) {
 	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
 
 	// Function 'main'
-	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
-	BB1 := bblock.NewBasicBlock(1, bblock.RETURN_STMT, 11)
+	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 10)
 
 	// Function 'integerToString'
-	BB2 := bblock.NewBasicBlock(2, bblock.FUNCTION_ENTRY, 13)
-	BB3 := bblock.NewBasicBlock(3, bblock.SWITCH_STATEMENT, 14)
-	BB4 := bblock.NewBasicBlock(4, bblock.RETURN_STMT, 16)
-	BB5 := bblock.NewBasicBlock(5, bblock.RETURN_STMT, 18)
-	BB6 := bblock.NewBasicBlock(6, bblock.RETURN_STMT, 20)
-	BB7 := bblock.NewBasicBlock(7, bblock.RETURN_STMT, 22)
-	BB8 := bblock.NewBasicBlock(8, bblock.RETURN_STMT, 24)
-
-	BB0.AddSuccessorBlock(BB1)
-	BB2.AddSuccessorBlock(BB3)
-	BB3.AddSuccessorBlock(BB4, BB5, BB6, BB7, BB8)
-
-	correctBasicBlocks := []*bblock.BasicBlock{BB0, BB1, BB2, BB3, BB4, BB5, BB6, BB7, BB8}
+	BB1 := bblock.NewBasicBlock(1, bblock.FUNCTION_ENTRY, 13)
+	BB2 := bblock.NewBasicBlock(2, bblock.SWITCH_STATEMENT, 14)
+	BB3 := bblock.NewBasicBlock(3, bblock.CASE_CLAUSE, 16)
+	BB4 := bblock.NewBasicBlock(4, bblock.CASE_CLAUSE, 18)
+	BB5 := bblock.NewBasicBlock(5, bblock.CASE_CLAUSE, 20)
+	BB6 := bblock.NewBasicBlock(6, bblock.CASE_CLAUSE, 22)
+
+	BB0.AddSuccessorBlock(EXIT)
+	BB1.AddSuccessorBlock(BB2)
+	BB2.AddSuccessorBlock(BB3, BB4, BB5, BB6)
+	BB3.AddSuccessorBlock(EXIT)
+	BB4.AddSuccessorBlock(EXIT)
+	BB5.AddSuccessorBlock(EXIT)
+	BB6.AddSuccessorBlock(EXIT)
+
+	correctBasicBlocks := []*bblock.BasicBlock{BB0, BB1, BB2, BB3, BB4, BB5, BB6}
 
 	//Test basic-blocks.
 	if err := VerifyBasicBlocks(basicBlocks, correctBasicBlocks); err != nil {
@@ -259,23 +268,20 @@ func TestSwitchControlFlowGraph(t *testing.T) {
 
 	// Control flow graph for function 'main'.
 	correctGraph[0].InsertEdge(&graph.Node{Value: START}, &graph.Node{Value: BB0})
-	correctGraph[0].InsertEdge(&graph.Node{Value: BB0}, &graph.Node{Value: BB1})
-	correctGraph[0].InsertEdge(&graph.Node{Value: BB1}, &graph.Node{Value: EXIT})
+	correctGraph[0].InsertEdge(&graph.Node{Value: BB0}, &graph.Node{Value: EXIT})
 	correctGraph[0].InsertEdge(&graph.Node{Value: EXIT}, &graph.Node{Value: START})
 
 	// Control flow graph for function 'integerToString'.
-	correctGraph[1].InsertEdge(&graph.Node{Value: START}, &graph.Node{Value: BB2})
+	correctGraph[1].InsertEdge(&graph.Node{Value: START}, &graph.Node{Value: BB1})
+	correctGraph[1].InsertEdge(&graph.Node{Value: BB1}, &graph.Node{Value: BB2})
 	correctGraph[1].InsertEdge(&graph.Node{Value: BB2}, &graph.Node{Value: BB3})
-	correctGraph[1].InsertEdge(&graph.Node{Value: BB3}, &graph.Node{Value: BB4})
-	correctGraph[1].InsertEdge(&graph.Node{Value: BB3}, &graph.Node{Value: BB5})
-	correctGraph[1].InsertEdge(&graph.Node{Value: BB3}, &graph.Node{Value: BB6})
-	correctGraph[1].InsertEdge(&graph.Node{Value: BB3}, &graph.Node{Value: BB7})
-	correctGraph[1].InsertEdge(&graph.Node{Value: BB3}, &graph.Node{Value: BB8})
+	correctGraph[1].InsertEdge(&graph.Node{Value: BB2}, &graph.Node{Value: BB4})
+	correctGraph[1].InsertEdge(&graph.Node{Value: BB2}, &graph.Node{Value: BB5})
+	correctGraph[1].InsertEdge(&graph.Node{Value: BB2}, &graph.Node{Value: BB6})
+	correctGraph[1].InsertEdge(&graph.Node{Value: BB3}, &graph.Node{Value: EXIT})
 	correctGraph[1].InsertEdge(&graph.Node{Value: BB4}, &graph.Node{Value: EXIT})
 	correctGraph[1].InsertEdge(&graph.Node{Value: BB5}, &graph.Node{Value: EXIT})
 	correctGraph[1].InsertEdge(&graph.Node{Value: BB6}, &graph.Node{Value: EXIT})
-	correctGraph[1].InsertEdge(&graph.Node{Value: BB7}, &graph.Node{Value: EXIT})
-	correctGraph[1].InsertEdge(&graph.Node{Value: BB8}, &graph.Node{Value: EXIT})
 	correctGraph[1].InsertEdge(&graph.Node{Value: EXIT}, &graph.Node{Value: START})
 
 	if err := VerifyControlFlowGraphs(expectedGraphs[0], correctGraph[0]); err != nil {
@@ -308,35 +314,34 @@ func TestGreatestCommonDivisorControlFlowGraph(t *testing.T) {
 	EXIT0 := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
 	BB0 := bblock.NewBasicBlock(0, bblock.FUNCTION_ENTRY, 8)
 	BB1 := bblock.NewBasicBlock(1, bblock.FOR_STATEMENT, 10)
-	BB2 := bblock.NewBasicBlock(2, bblock.FOR_BODY, 13)
-	BB3 := bblock.NewBasicBlock(3, bblock.RETURN_STMT, 14)
+	BB2 := bblock.NewBasicBlock(2, bblock.FOR_BODY, 12)
+	BB3 := bblock.NewBasicBlock(3, bblock.FOR_DONE, 14)
 
 	// Function 'main'
 	START1 := bblock.NewBasicBlock(-1, bblock.START, 0)
 	EXIT1 := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
-	BB4 := bblock.NewBasicBlock(4, bblock.FUNCTION_ENTRY, 17)
-	BB5 := bblock.NewBasicBlock(5, bblock.RETURN_STMT, 21)
+	BB4 := bblock.NewBasicBlock(4, bblock.FUNCTION_ENTRY, 20)
 
 	BB0.AddSuccessorBlock(BB1)
 	BB1.AddSuccessorBlock(BB2, BB3)
 	BB2.AddSuccessorBlock(BB1)
-	BB4.AddSuccessorBlock(BB5)
+	BB3.AddSuccessorBlock(EXIT0)
+	BB4.AddSuccessorBlock(EXIT1)
 
-	correctBasicBlocks := []*bblock.BasicBlock{BB0, BB1, BB2, BB3, BB4, BB5}
+	correctBasicBlocks := []*bblock.BasicBlock{BB0, BB1, BB2, BB3, BB4}
 
 	// Function 'gcd'
 	correctGraph[0].InsertEdge(&graph.Node{Value: START0}, &graph.Node{Value: BB0})
 	correctGraph[0].InsertEdge(&graph.Node{Value: BB0}, &graph.Node{Value: BB1})
 	correctGraph[0].InsertEdge(&graph.Node{Value: BB1}, &graph.Node{Value: BB2})
-	correctGraph[0].InsertEdge(&graph.Node{Value: BB2}, &graph.Node{Value: BB1})
 	correctGraph[0].InsertEdge(&graph.Node{Value: BB1}, &graph.Node{Value: BB3})
+	correctGraph[0].InsertEdge(&graph.Node{Value: BB2}, &graph.Node{Value: BB1})
 	correctGraph[0].InsertEdge(&graph.Node{Value: BB3}, &graph.Node{Value: EXIT0})
 	correctGraph[0].InsertEdge(&graph.Node{Value: EXIT0}, &graph.Node{Value: START0})
 
 	// Function 'main'
 	correctGraph[1].InsertEdge(&graph.Node{Value: START1}, &graph.Node{Value: BB4})
-	correctGraph[1].InsertEdge(&graph.Node{Value: BB4}, &graph.Node{Value: BB5})
-	correctGraph[1].InsertEdge(&graph.Node{Value: BB5}, &graph.Node{Value: EXIT1})
+	correctGraph[1].InsertEdge(&graph.Node{Value: BB4}, &graph.Node{Value: EXIT1})
 	correctGraph[1].InsertEdge(&graph.Node{Value: EXIT1}, &graph.Node{Value: START1})
 
 	// Test basic-blocks.
@@ -351,3 +356,69 @@ func TestGreatestCommonDivisorControlFlowGraph(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestBuildControlFlowGraph(t *testing.T) {
+	filePath := "./testcode/_build.go"
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, filePath, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	if _, err := new(types.Config).Check("main", fileSet, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+	check, mustPositive := file.Decls[0].(*ast.FuncDecl), file.Decls[1].(*ast.FuncDecl)
+	apply := file.Decls[2].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Values[0].(*ast.FuncLit)
+
+	// The type information tells panic is a local function in check.
+	for _, typeInfo := range []*types.Info{nil, info} {
+		cfg := cfgraph.Build(fileSet, check, typeInfo)
+		noReturn := cfg.GetNumberOfNodes() == 7
+		if noReturn != (typeInfo == nil) {
+			t.Errorf("Calling panic in check should leave the function only without type information, graph has %d nodes!",
+				cfg.GetNumberOfNodes())
+		}
+	}
+
+	cfg := cfgraph.Build(fileSet, mustPositive, info)
+	if cfg.Root.Value.(*bblock.BasicBlock).FunctionName != "mustPositive" || len(cfg.BasicBlocks) != 4 {
+		t.Fatalf("Graph should be built from the 4 basic-blocks of mustPositive, not %v!", cfg.BasicBlocks)
+	}
+	if err := VerifyControlFlowGraphs(cfg, mustPositiveGraph(cfg.BasicBlocks)); err != nil {
+		t.Error(err)
+	}
+	returnStmt := mustPositive.Body.List[1]
+	if basicBlock := cfg.BasicBlock(returnStmt); basicBlock == nil || basicBlock.Type != bblock.IF_DONE ||
+		!cfg.IsReachable(basicBlock) {
+		t.Errorf("Return statement should be in the reachable IF_DONE block, not %v!", basicBlock)
+	}
+
+	cfg = cfgraph.Build(fileSet, apply, nil)
+	deferred := cfg.BasicBlocks[len(cfg.BasicBlocks)-1]
+	if len(cfg.BasicBlocks) != 2 || deferred.Type != bblock.DEFERRED_CALLS || !deferred.Recovers {
+		t.Errorf("Function literal should return through the recovering deferred call, but has blocks %v!", cfg.BasicBlocks)
+	}
+	if cfgraph.Build(fileSet, file, info) != nil {
+		t.Error("Graph should only be built for functions!")
+	}
+}
+
+// mustPositiveGraph returns the correct control-flow graph of mustPositive in _build.go.
+func mustPositiveGraph(basicBlocks []*bblock.BasicBlock) *graph.Graph {
+	START := bblock.NewBasicBlock(-1, bblock.START, 0)
+	EXIT := bblock.NewBasicBlock(-1, bblock.EXIT, 0)
+	NO_RETURN := bblock.NewBasicBlock(-1, bblock.NO_RETURN, 0)
+	BB0, BB1, BB2, BB3 := basicBlocks[0], basicBlocks[1], basicBlocks[2], basicBlocks[3]
+
+	correctGraph := graph.NewGraph()
+	correctGraph.InsertEdge(&graph.Node{Value: START}, &graph.Node{Value: BB0})
+	correctGraph.InsertEdge(&graph.Node{Value: BB0}, &graph.Node{Value: BB1})
+	correctGraph.InsertEdge(&graph.Node{Value: BB1}, &graph.Node{Value: BB2})
+	correctGraph.InsertEdge(&graph.Node{Value: BB1}, &graph.Node{Value: BB3})
+	correctGraph.InsertEdge(&graph.Node{Value: BB2}, &graph.Node{Value: NO_RETURN})
+	correctGraph.InsertEdge(&graph.Node{Value: NO_RETURN}, &graph.Node{Value: EXIT})
+	correctGraph.InsertEdge(&graph.Node{Value: BB3}, &graph.Node{Value: EXIT})
+	correctGraph.InsertEdge(&graph.Node{Value: EXIT}, &graph.Node{Value: START})
+	return correctGraph
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package main
+
+func check(ok bool) {
+	panic := func(message string) {}
+	if !ok {
+		panic("not ok")
+	}
+}
+
+func mustPositive(n int) int {
+	if n < 0 {
+		panic("negative")
+	}
+	return n
+}
+
+var apply = func(n int) (result int) {
+	defer func() { recover() }()
+	return mustPositive(n)
+}
@@ -8,15 +8,18 @@ import (
 	"github.com/chrisbbe/GoAnalysis/analyzer/linter/ccomplexity/bblock"
 	"github.com/chrisbbe/GoAnalysis/analyzer/linter/ccomplexity/cfgraph"
 	"github.com/chrisbbe/GoAnalysis/analyzer/linter/ccomplexity/graph"
+	"go/ast"
+	"go/token"
 )
 
 // FunctionComplexity represents cyclomatic complexity in a function or method.
 type FunctionComplexity struct {
 	Name             string                    //Function name.
 	SrcLine          int                       //Line number in source file where func is declared.
+	Function         ast.Node                  //Function declaration or literal.
 	Complexity       int                       //Cyclomatic complexity value.
 	ControlFlowGraph *cfgraph.ControlFlowGraph //Control-flow graph in function.
-	BasicBlocks      []*bblock.BasicBlock      //Basic-blocks in function.
+	BasicBlocks      []*bblock.BasicBlock      //Basic-blocks in function, also those control can not reach.
 }
 
 func (funCC *FunctionComplexity) String() string {
@@ -39,8 +42,11 @@ func (function *FunctionComplexity) GetSCComponents() []*graph.StronglyConnected
 	return function.ControlFlowGraph.GetSCComponents()
 }
 
+// GetCyclomaticComplexity returns the cyclomatic complexity E - N + 2 of the control-flow graph, where
+// the edge from Exit back to Start is one of the E edges. The graph is connected, but not strongly
+// connected if a loop never terminates.
 func GetCyclomaticComplexity(cfg *cfgraph.ControlFlowGraph) int {
-	return cfg.GetNumberOfEdges() - cfg.GetNumberOfNodes() + cfg.GetNumberOfSCComponents()
+	return cfg.GetNumberOfEdges() - cfg.GetNumberOfNodes() + 1
 }
 
 func GetCyclomaticComplexityFunctionLevel(goFilePath string, goSrcFile []byte) (functions []*FunctionComplexity, err error) {
@@ -48,17 +54,27 @@ func GetCyclomaticComplexityFunctionLevel(goFilePath string, goSrcFile []byte) (
 	if err != nil {
 		return nil, err
 	}
+	return getCyclomaticComplexity(blocks), nil
+}
+
+// GetCyclomaticComplexityOfFile returns the cyclomatic complexity of the functions in the
+// already parsed file, positions are resolved through fileSet.
+func GetCyclomaticComplexityOfFile(fileSet *token.FileSet, file *ast.File) []*FunctionComplexity {
+	return getCyclomaticComplexity(bblock.GetBasicBlocksFromFile(fileSet, file))
+}
 
+func getCyclomaticComplexity(blocks []*bblock.BasicBlock) (functions []*FunctionComplexity) {
 	for _, cfg := range cfgraph.GetControlFlowGraph(blocks) {
 		complexity := GetCyclomaticComplexity(cfg)
 		funcBlock := cfg.Root.Value.(*bblock.BasicBlock)
 		functions = append(functions, &FunctionComplexity{
 			Name:             funcBlock.FunctionName,
 			SrcLine:          funcBlock.FunctionDeclLine,
+			Function:         funcBlock.Function,
 			Complexity:       complexity,
 			ControlFlowGraph: cfg,
-			BasicBlocks:      blocks,
+			BasicBlocks:      cfg.BasicBlocks,
 		})
 	}
-	return functions, nil
+	return functions
 }
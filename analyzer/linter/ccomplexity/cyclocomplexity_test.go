@@ -103,7 +103,7 @@ func TestSwitcherComplexity(t *testing.T) {
 
 	correctCyclomaticComplexity := []ccomplexity.FunctionComplexity{
 		{Name: "main", Complexity: 1},
-		{Name: "monthNumberToString", Complexity: 14},
+		{Name: "monthNumberToString", Complexity: 13},
 	}
 
 	if err := verifyCyclomaticComplexity(expectedCyclomaticComplexity, correctCyclomaticComplexity); err != nil {
@@ -130,3 +130,115 @@ func TestGreatestCommonDivisorComplexity(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestBranchesComplexity(t *testing.T) {
+	filePath := "./testcode/_branches.go"
+	srcFile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedCyclomaticComplexity, err := ccomplexity.GetCyclomaticComplexityFunctionLevel(filePath, srcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two loops, three cases without default and an if statement.
+	correctCyclomaticComplexity := []ccomplexity.FunctionComplexity{
+		{Name: "main", Complexity: 7},
+	}
+
+	if err := verifyCyclomaticComplexity(expectedCyclomaticComplexity, correctCyclomaticComplexity); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeferPanicComplexity(t *testing.T) {
+	filePath := "./testcode/_defer.go"
+	srcFile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedCyclomaticComplexity, err := ccomplexity.GetCyclomaticComplexityFunctionLevel(filePath, srcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Recovering from the panic is a path of its own.
+	correctCyclomaticComplexity := []ccomplexity.FunctionComplexity{
+		{Name: "closeAll", Complexity: 3},
+		{Name: "safeDivide", Complexity: 3},
+		{Name: "safeDivide.func1", Complexity: 2},
+		{Name: "mustOpen", Complexity: 3},
+	}
+
+	if err := verifyCyclomaticComplexity(expectedCyclomaticComplexity, correctCyclomaticComplexity); err != nil {
+		t.Error(err)
+	}
+	// The graphs have a NoReturn node, but not the unreachable block after log.Fatal.
+	if nodes := expectedCyclomaticComplexity[3].GetNumberOfNodes(); nodes != 10 {
+		t.Errorf("Control-flow graph of mustOpen should have 10 nodes, not %d!", nodes)
+	}
+}
+
+func TestClosureComplexity(t *testing.T) {
+	filePath := "./testcode/_closures.go"
+	srcFile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedCyclomaticComplexity, err := ccomplexity.GetCyclomaticComplexityFunctionLevel(filePath, srcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Function literals are named like the compiler does, not adding to the enclosing function.
+	correctCyclomaticComplexity := []ccomplexity.FunctionComplexity{
+		{Name: "main", Complexity: 1},
+		{Name: "main.func1", Complexity: 1},
+		{Name: "main.func2", Complexity: 2},
+		{Name: "main.func2.1", Complexity: 2},
+	}
+
+	if err := verifyCyclomaticComplexity(expectedCyclomaticComplexity, correctCyclomaticComplexity); err != nil {
+		t.Error(err)
+	}
+	for index, srcLine := range []int{11, 13, 17, 19} {
+		if expectedCyclomaticComplexity[index].SrcLine != srcLine {
+			t.Errorf("Function %s should be declared at line %d, not %d!", expectedCyclomaticComplexity[index].Name,
+				srcLine, expectedCyclomaticComplexity[index].SrcLine)
+		}
+	}
+}
+
+// Methods are qualified by their receiver type, so their function literals do not share names.
+func TestMethodComplexityNames(t *testing.T) {
+	srcFile := []byte(`package main
+
+type T struct{}
+
+type U struct{}
+
+type G[K comparable] struct{}
+
+func (T) M() { func() {}() }
+
+func (u *U) M() { func() {}() }
+
+func (g *G[K]) M() {}
+`)
+	expectedCyclomaticComplexity, err := ccomplexity.GetCyclomaticComplexityFunctionLevel("methods.go", srcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	correctCyclomaticComplexity := []ccomplexity.FunctionComplexity{
+		{Name: "T.M", Complexity: 1},
+		{Name: "T.M.func1", Complexity: 1},
+		{Name: "(*U).M", Complexity: 1},
+		{Name: "(*U).M.func1", Complexity: 1},
+		{Name: "(*G[...]).M", Complexity: 1},
+	}
+	if err := verifyCyclomaticComplexity(expectedCyclomaticComplexity, correctCyclomaticComplexity); err != nil {
+		t.Error(err)
+	}
+}
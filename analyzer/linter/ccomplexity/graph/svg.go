@@ -0,0 +1,206 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Layout settings used by WriteSVG, in pixels.
+const (
+	svgMargin      = 20
+	svgNodeHeight  = 30
+	svgNodePadding = 12
+	svgCharWidth   = 7 // Approximate width of a character in the monospace label font.
+	svgLayerGap    = 50
+	svgNodeGap     = 30
+	svgLoopOffset  = 40
+)
+
+type svgNode struct {
+	node          *Node
+	layer         int
+	x, y, width   int
+	order         float64
+	dfsIndex      int
+	onStack, done bool
+}
+
+func (n *svgNode) centerX() int {
+	return n.x + n.width/2
+}
+
+// WriteSVG draws the graph as a standalone SVG image to w, without relying on Graphviz.
+// Nodes are placed in layers from top to bottom in depth-first order starting at roots,
+// or at the root node if none are given. Edges going upwards, like loops, are drawn as
+// curves on the right side of the nodes.
+func (graph *Graph) WriteSVG(w io.Writer, roots ...*Node) error {
+	nodes := make(map[*Node]*svgNode)
+	var ordered []*svgNode
+	backEdges := make(map[[2]*Node]bool)
+
+	// Depth-first search, recording the discovery order and the edges going back to a node on the stack.
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		n := &svgNode{node: node, dfsIndex: len(ordered), onStack: true}
+		nodes[node] = n
+		ordered = append(ordered, n)
+		for _, outNode := range node.outEdges {
+			if outN, ok := nodes[outNode]; !ok {
+				visit(outNode)
+			} else if outN.onStack {
+				backEdges[[2]*Node{node, outNode}] = true
+			}
+		}
+		n.onStack = false
+	}
+
+	if len(roots) == 0 && graph.Root != nil {
+		roots = []*Node{graph.Root}
+	}
+	for _, root := range roots {
+		if _, ok := nodes[root]; !ok && root != nil {
+			visit(root)
+		}
+	}
+	for _, uid := range graph.sortedUIDs() {
+		if _, ok := nodes[graph.Nodes[uid]]; !ok {
+			visit(graph.Nodes[uid])
+		}
+	}
+
+	// Longest path layering on the acyclic graph left when back-edges are ignored.
+	var assignLayer func(n *svgNode) int
+	assignLayer = func(n *svgNode) int {
+		if n.done {
+			return n.layer
+		}
+		n.done = true
+		for _, inNode := range n.node.inEdges {
+			if inN, ok := nodes[inNode]; ok && !backEdges[[2]*Node{inNode, n.node}] {
+				if layer := assignLayer(inN) + 1; layer > n.layer {
+					n.layer = layer
+				}
+			}
+		}
+		return n.layer
+	}
+	var layers [][]*svgNode
+	for _, n := range ordered {
+		assignLayer(n)
+	}
+	for _, n := range ordered {
+		for len(layers) <= n.layer {
+			layers = append(layers, nil)
+		}
+		layers[n.layer] = append(layers[n.layer], n)
+	}
+
+	// Order nodes in a layer by the average position of their parents, reducing edge crossings.
+	for layerIndex, layer := range layers {
+		for _, n := range layer {
+			n.order = float64(n.dfsIndex)
+			if layerIndex == 0 {
+				continue
+			}
+			sum, count := 0.0, 0
+			for _, inNode := range n.node.inEdges {
+				if inN, ok := nodes[inNode]; ok && inN.layer < n.layer {
+					sum += inN.order
+					count++
+				}
+			}
+			if count > 0 {
+				n.order = sum / float64(count)
+			}
+		}
+		sort.SliceStable(layer, func(i, j int) bool { return layer[i].order < layer[j].order })
+		for position, n := range layer {
+			n.order = float64(position)
+		}
+	}
+
+	// Assign coordinates, centering each layer horizontally.
+	maxLayerWidth := 0
+	layerWidths := make([]int, len(layers))
+	for layerIndex, layer := range layers {
+		for _, n := range layer {
+			n.width = len(n.node.String())*svgCharWidth + 2*svgNodePadding
+			layerWidths[layerIndex] += n.width
+		}
+		layerWidths[layerIndex] += (len(layer) - 1) * svgNodeGap
+		if layerWidths[layerIndex] > maxLayerWidth {
+			maxLayerWidth = layerWidths[layerIndex]
+		}
+	}
+	for layerIndex, layer := range layers {
+		x := svgMargin + (maxLayerWidth-layerWidths[layerIndex])/2
+		for _, n := range layer {
+			n.x = x
+			n.y = svgMargin + layerIndex*(svgNodeHeight+svgLayerGap)
+			x += n.width + svgNodeGap
+		}
+	}
+
+	width := maxLayerWidth + 2*svgMargin + svgLoopOffset
+	height := len(layers)*(svgNodeHeight+svgLayerGap) - svgLayerGap + 2*svgMargin
+	if len(layers) == 0 {
+		height = 2 * svgMargin
+	}
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" "+
+		"font-family=\"monospace\" font-size=\"12\">\n", width, height, width, height)
+	content.WriteString("<defs><marker id=\"arrow\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"8\" " +
+		"markerHeight=\"8\" orient=\"auto\"><path d=\"M 0 0 L 10 5 L 0 10 z\"/></marker></defs>\n")
+
+	for _, n := range ordered {
+		for _, outNode := range n.node.outEdges {
+			outN := nodes[outNode]
+			if outN.layer > n.layer {
+				fmt.Fprintf(&content, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"black\" marker-end=\"url(#arrow)\"/>\n",
+					n.centerX(), n.y+svgNodeHeight, outN.centerX(), outN.y)
+			} else {
+				// Upwards or same layer edge, curve around the right side of both nodes.
+				startX, startY := n.x+n.width, n.y+svgNodeHeight/2
+				endX, endY := outN.x+outN.width, outN.y+svgNodeHeight/2
+				bendX := startX
+				if endX > bendX {
+					bendX = endX
+				}
+				bendX += svgLoopOffset
+				fmt.Fprintf(&content, "<path d=\"M %d %d C %d %d, %d %d, %d %d\" fill=\"none\" stroke=\"gray\" marker-end=\"url(#arrow)\"/>\n",
+					startX, startY, bendX, startY, bendX, endY-svgNodeHeight/2, endX, endY-svgNodeHeight/4)
+			}
+		}
+	}
+
+	for _, n := range ordered {
+		fmt.Fprintf(&content, "<g><rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"%d\" fill=\"white\" stroke=\"black\"/>",
+			n.x, n.y, n.width, svgNodeHeight, svgNodeHeight/2)
+		fmt.Fprintf(&content, "<text x=\"%d\" y=\"%d\" text-anchor=\"middle\">", n.centerX(), n.y+svgNodeHeight/2+4)
+		if err := xml.EscapeText(&content, []byte(n.node.String())); err != nil {
+			return err
+		}
+		content.WriteString("</text></g>\n")
+	}
+	content.WriteString("</svg>\n")
+
+	_, err := content.WriteTo(w)
+	return err
+}
+
+// sortedUIDs returns the UIDs of all nodes in the graph in sorted order.
+func (graph *Graph) sortedUIDs() []string {
+	uids := make([]string, 0, len(graph.Nodes))
+	for uid := range graph.Nodes {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	return uids
+}
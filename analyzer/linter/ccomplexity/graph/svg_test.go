@@ -0,0 +1,72 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package graph_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter/ccomplexity/graph"
+)
+
+type svgImage struct {
+	Nodes []struct {
+		Rect struct {
+			Y int `xml:"y,attr"`
+		} `xml:"rect"`
+		Text string `xml:"text"`
+	} `xml:"g"`
+	Lines []struct{} `xml:"line"`
+	Paths []struct{} `xml:"path"`
+}
+
+func TestWriteSVG(t *testing.T) {
+	a := &graph.Node{Value: Letter{"A"}}
+	b := &graph.Node{Value: Letter{"B"}}
+	c := &graph.Node{Value: Letter{"C<"}}
+	d := &graph.Node{Value: Letter{"D"}}
+
+	directedGraph := graph.NewGraph()
+	directedGraph.InsertEdge(a, b)
+	directedGraph.InsertEdge(a, c)
+	directedGraph.InsertEdge(b, d)
+	directedGraph.InsertEdge(c, d)
+	directedGraph.InsertEdge(d, a) // Back-edge.
+
+	var buffer bytes.Buffer
+	if err := directedGraph.WriteSVG(&buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	var image svgImage
+	if err := xml.Unmarshal(buffer.Bytes(), &image); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(image.Nodes) != 4 {
+		t.Fatalf("Number of nodes should be 4, but are %d!", len(image.Nodes))
+	}
+	if len(image.Lines) != 4 || len(image.Paths) != 1 {
+		t.Fatalf("Image should have 4 straight edges and 1 back-edge, but has %d and %d!", len(image.Lines), len(image.Paths))
+	}
+
+	// Nodes are layered top to bottom: A, then B and C, then D.
+	layers := map[string]int{}
+	for _, node := range image.Nodes {
+		layers[node.Text] = node.Rect.Y
+	}
+	if !(layers["A"] < layers["B"] && layers["B"] == layers["C<"] && layers["C<"] < layers["D"]) {
+		t.Errorf("Nodes are not layered correctly: %v", layers)
+	}
+
+	// Drawing the same graph twice gives the same image.
+	var secondBuffer bytes.Buffer
+	if err := directedGraph.WriteSVG(&secondBuffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), secondBuffer.Bytes()) {
+		t.Error("Drawing the same graph twice should give the same image!")
+	}
+}
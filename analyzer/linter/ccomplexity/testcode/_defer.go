@@ -0,0 +1,41 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package main
+
+import (
+	"fmt"
+	"log"
+	xos "os"
+)
+
+func closeAll(files []string) {
+	defer fmt.Println("closed")
+	for _, file := range files {
+		if file == "" {
+			panic("empty file name")
+		}
+	}
+}
+
+func safeDivide(a, b int) (result int) {
+	defer func() {
+		if recover() != nil {
+			result = 0
+		}
+	}()
+	if b == 0 {
+		panic("division by zero")
+	}
+	return a / b
+}
+
+func mustOpen(name string) {
+	if name == "" {
+		log.Fatal("no name")
+		fmt.Println("dead")
+	}
+	if name == "-" {
+		xos.Exit(1)
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// CONFIG_FILE_NAME is the name of the project configuration file, searched
+// for from the analysed directory and upwards. Only JSON is supported.
+const CONFIG_FILE_NAME = ".goanalysis.json"
+
+// RuleConfig holds the project specific settings of a single rule.
+type RuleConfig struct {
+	Enabled  *bool                  `json:"enabled,omitempty"`  // Rule is enabled unless set to false.
+	Severity *Severity              `json:"severity,omitempty"` // Overrides the default severity of the rule.
+	Params   map[string]interface{} `json:"params,omitempty"`   // Rule parameters, e.g. "limit" for CYCLOMATIC_COMPLEXITY.
+}
+
+// Config controls which files are analysed and how the rules are applied.
+//
+// Example .goanalysis.json:
+//
+//	{
+//	  "rules": {
+//	    "FMT_PRINTING": {"enabled": false},
+//	    "CYCLOMATIC_COMPLEXITY": {"severity": "CRITICAL", "params": {"limit": 15}}
+//	  },
+//	  "exclude": ["vendor/**", "*.pb.go"]
+//	}
+type Config struct {
+	Dir  string `json:"-"` // Root directory of Go source files to be analysed.
+	Path string `json:"-"` // Path to the configuration file, empty if none was found.
+
+	Patterns []string      `json:"-"` // Package patterns relative to Dir, like "./...", DEFAULT_PATTERN if empty.
+	Builds   []BuildConfig `json:"-"` // Build configurations to analyse, the default configuration if empty.
+	Jobs     int           `json:"-"` // Number of packages analysed concurrently, GOMAXPROCS if less than 1.
+	CacheDir string        `json:"-"` // Directory to cache analysis results in, no caching if empty.
+
+	// Overlay maps absolute file paths to their content, replacing the files on disk, like
+	// the unsaved buffers of an editor. Results are not cached if set.
+	Overlay map[string][]byte `json:"-"`
+
+	Rules   map[Rule]*RuleConfig `json:"rules,omitempty"`
+	Include []string             `json:"include,omitempty"` // Globs of files to analyse, all files if empty.
+	Exclude []string             `json:"exclude,omitempty"` // Globs of files and directories to skip.
+
+	root string // Directory the include and exclude globs are relative to.
+}
+
+// NewConfig returns the default configuration for analysing dir, having all rules enabled.
+func NewConfig(dir string) *Config {
+	return &Config{Dir: dir, root: dir}
+}
+
+// LoadConfig searches for CONFIG_FILE_NAME from dir and upwards, returning the
+// first found configuration or the default configuration if there is none.
+func LoadConfig(dir string) (*Config, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for searchDir := absDir; ; {
+		configPath := filepath.Join(searchDir, CONFIG_FILE_NAME)
+		if _, err := os.Stat(configPath); err == nil {
+			return LoadConfigFile(configPath, dir)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		parentDir := filepath.Dir(searchDir)
+		if parentDir == searchDir {
+			return NewConfig(dir), nil
+		}
+		searchDir = parentDir
+	}
+}
+
+// LoadConfigFile reads the configuration file at configPath for analysing dir, which must
+// be JSON whatever its name is. Globs in the file are relative to the directory holding the file.
+func LoadConfigFile(configPath string, dir string) (*Config, error) {
+	file, err := os.Open(configPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	config := NewConfig(dir)
+	if err := json.NewDecoder(file).Decode(config); err != nil {
+		return nil, fmt.Errorf("%s: %s", configPath, err)
+	}
+
+	if config.root, err = filepath.Abs(filepath.Dir(configPath)); err != nil {
+		return nil, err
+	}
+	config.Path = configPath
+	return config, config.validate()
+}
+
+// validate checks that the configuration only refers to registered rules and their parameters,
+// that integer parameters are whole numbers and that globs are valid.
+func (config *Config) validate() error {
+	for rule, ruleConfig := range config.Rules {
+		info := LookupRule(rule)
+		if info == nil {
+			return fmt.Errorf("%s: unknown rule %s", config.Path, rule)
+		}
+		if ruleConfig == nil {
+			continue
+		}
+		for name, value := range ruleConfig.Params {
+			if !hasParam(info.IntParams, name) {
+				return fmt.Errorf("%s: unknown parameter %q of rule %s", config.Path, name, rule)
+			}
+			if number, ok := value.(float64); !ok || float64(int(number)) != number {
+				return fmt.Errorf("%s: parameter %q of rule %s must be an integer, not %v", config.Path, name, rule, value)
+			}
+		}
+	}
+	for _, glob := range append(config.Include, config.Exclude...) {
+		if _, err := globToRegexp(glob); err != nil {
+			return fmt.Errorf("%s: %s", config.Path, err)
+		}
+	}
+	return nil
+}
+
+// hasParam returns true if name is among the parameter names.
+func hasParam(names []string, name string) bool {
+	for _, paramName := range names {
+		if paramName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleEnabled returns true if rule should be checked.
+func (config *Config) RuleEnabled(rule Rule) bool {
+	if ruleConfig := config.Rules[rule]; ruleConfig != nil && ruleConfig.Enabled != nil {
+		return *ruleConfig.Enabled
+	}
+	return true
+}
+
+// RuleSeverity returns the configured severity of rule, or the default severity of the rule.
+func (config *Config) RuleSeverity(rule Rule) Severity {
+	if ruleConfig := config.Rules[rule]; ruleConfig != nil && ruleConfig.Severity != nil {
+		return *ruleConfig.Severity
+	}
+	if info := LookupRule(rule); info != nil {
+		return info.Severity
+	}
+	return INFO
+}
+
+// IntParam returns the integer parameter name of rule, or fallback if it is not set.
+func (config *Config) IntParam(rule Rule, name string, fallback int) int {
+	if ruleConfig := config.Rules[rule]; ruleConfig != nil {
+		switch value := ruleConfig.Params[name].(type) {
+		case float64:
+			return int(value)
+		case int:
+			return value
+		}
+	}
+	return fallback
+}
+
+// FileIncluded returns true if the Go source file at filePath should be analysed.
+func (config *Config) FileIncluded(filePath string) bool {
+	relPath, ok := config.relativePath(filePath)
+	if !ok {
+		return true
+	}
+	if matchAnyGlob(config.Exclude, relPath) {
+		return false
+	}
+	return len(config.Include) == 0 || matchAnyGlob(config.Include, relPath)
+}
+
+// DirExcluded returns true if the whole directory at dirPath should be skipped.
+func (config *Config) DirExcluded(dirPath string) bool {
+	relPath, ok := config.relativePath(dirPath)
+	if !ok || relPath == "." {
+		return false
+	}
+	// A trailing slash lets "vendor/**" match the vendor directory itself.
+	return matchAnyGlob(config.Exclude, relPath+"/")
+}
+
+// relativePath returns filePath relative to the configuration root, using forward slashes.
+func (config *Config) relativePath(filePath string) (string, bool) {
+	absRoot, err := filepath.Abs(config.root)
+	if err != nil {
+		return "", false
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", false
+	}
+	relPath, err := filepath.Rel(absRoot, absPath)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return "", false
+	}
+	return filepath.ToSlash(relPath), true
+}
+
+// matchAnyGlob returns true if relPath matches one of the globs. Globs without a
+// slash are also matched against the base name, so "*.pb.go" matches in every directory.
+func matchAnyGlob(globs []string, relPath string) bool {
+	for _, glob := range globs {
+		globRegexp, err := globToRegexp(glob)
+		if err != nil {
+			continue
+		}
+		if globRegexp.MatchString(relPath) {
+			return true
+		}
+		if !strings.Contains(glob, "/") && globRegexp.MatchString(path.Base(relPath)) {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegexp converts a glob to a regular expression, where '**' matches any
+// number of directories, '*' matches within a single path element and '?' matches
+// a single character.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var expr bytes.Buffer
+	expr.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				expr.WriteString("(.*/)?")
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	globRegexp, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %s", glob, err)
+	}
+	return globRegexp, nil
+}
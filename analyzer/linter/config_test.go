@@ -0,0 +1,96 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+// The configuration in testcode/config disables FMT_PRINTING and ERROR_IGNORED, lowers the
+// cyclomatic complexity limit to 1 and excludes the generated directory.
+func TestDetectionWithConfigFile(t *testing.T) {
+	config, err := linter.LoadConfig("./testcode/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedViolations, err := linter.DetectViolations(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actualViolations := []actualViolation{
+		{SrcLine: 11, Type: linter.CYCLOMATIC_COMPLEXITY},
+		{SrcLine: 12, Type: linter.EMPTY_IF_BODY},
+	}
+
+	if len(expectedViolations) != 1 {
+		t.Fatalf("Number of packages containing violations should be 1, but are %d!", len(expectedViolations))
+	}
+	violations := expectedViolations[0].Violations[0].Violations
+	if err := verifyViolations(violations, actualViolations); err != nil {
+		t.Fatal(err)
+	}
+	if violations[0].Severity != linter.CRITICAL {
+		t.Errorf("Violation (%s) should have severity %s, and not %s!", violations[0].Type, linter.CRITICAL, violations[0].Severity)
+	}
+	if violations[1].Severity != linter.INFO {
+		t.Errorf("Violation (%s) should have severity %s, and not %s!", violations[1].Type, linter.INFO, violations[1].Severity)
+	}
+}
+
+// The configuration file is searched for upwards, with globs relative to the directory holding it.
+func TestConfigFileFoundInParentDirectory(t *testing.T) {
+	config, err := linter.LoadConfig("./testcode/config/generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Path) == 0 {
+		t.Fatal("Configuration file in parent directory should be found!")
+	}
+
+	expectedViolations, err := linter.DetectViolations(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expectedViolations) != 0 {
+		t.Fatalf("Excluded directory should have no violations, but has %d packages with violations!", len(expectedViolations))
+	}
+}
+
+func TestConfigFileIncluded(t *testing.T) {
+	config := linter.NewConfig("project")
+	config.Include = []string{"cmd/**"}
+	config.Exclude = []string{"**/testdata/**", "*.pb.go"}
+
+	files := map[string]bool{
+		"project/cmd/main.go":               true,
+		"project/cmd/tool/tool.go":          true,
+		"project/cmd/tool/service.pb.go":    false,
+		"project/cmd/testdata/fixture.go":   false,
+		"project/internal/helper/helper.go": false,
+	}
+	for filePath, included := range files {
+		if config.FileIncluded(filePath) != included {
+			t.Errorf("FileIncluded(%s) should be %t!", filePath, included)
+		}
+	}
+}
+
+// Rule parameters must be known and whole numbers, not silently ignored or truncated.
+func TestConfigFileInvalidParams(t *testing.T) {
+	for _, params := range []string{`{"limit": "15"}`, `{"limit": 15.5}`, `{"limit": true}`, `{"limt": 15}`} {
+		configPath := filepath.Join(t.TempDir(), linter.CONFIG_FILE_NAME)
+		content := `{"rules": {"CYCLOMATIC_COMPLEXITY": {"params": ` + params + `}}}`
+		if err := ioutil.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := linter.LoadConfigFile(configPath, "."); err == nil {
+			t.Errorf("Configuration with params %s should be rejected!", params)
+		}
+	}
+}
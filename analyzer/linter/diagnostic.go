@@ -0,0 +1,146 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter
+
+import (
+	"fmt"
+	"go/ast"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DiagnosticKind tells what kept a package from being analysed completely.
+type DiagnosticKind string
+
+const (
+	LIST_ERROR     DiagnosticKind = "LIST_ERROR"     // Package could not be found or loaded by the go command.
+	PARSE_ERROR    DiagnosticKind = "PARSE_ERROR"    // Source file has syntax errors, the syntax tree is incomplete.
+	TYPE_ERROR     DiagnosticKind = "TYPE_ERROR"     // Package could not be type checked, the type information is incomplete.
+	ANALYSIS_ERROR DiagnosticKind = "ANALYSIS_ERROR" // Source file could not be analysed, e.g. not read.
+)
+
+// Diagnostic is a problem found while loading or analysing a package, which may cause
+// violations to be missed. Analysis continues for other packages.
+type Diagnostic struct {
+	Kind     DiagnosticKind
+	FilePath string `json:",omitempty"` // Empty if the problem is not located in a file.
+	Line     int    `json:",omitempty"` // Starts at 1, 0 if unknown.
+	Column   int    `json:",omitempty"` // Starts at 1, 0 if unknown.
+	Message  string
+	Builds   []string // Build configurations the problem was found under.
+}
+
+// String returns the diagnostic like "main.go:10:2: undefined: x (TYPE_ERROR)".
+func (diagnostic *Diagnostic) String() string {
+	position := diagnostic.FilePath
+	if diagnostic.Line > 0 {
+		position += fmt.Sprintf(":%d", diagnostic.Line)
+		if diagnostic.Column > 0 {
+			position += fmt.Sprintf(":%d", diagnostic.Column)
+		}
+	}
+	if position == "" {
+		return fmt.Sprintf("%s (%s)", diagnostic.Message, diagnostic.Kind)
+	}
+	return fmt.Sprintf("%s: %s (%s)", position, diagnostic.Message, diagnostic.Kind)
+}
+
+// Incomplete returns true if the diagnostic tells the package could not be fully parsed and type
+// checked, so violations needing syntax or type information may be missed.
+func (diagnostic *Diagnostic) Incomplete() bool {
+	return diagnostic.Kind == PARSE_ERROR || diagnostic.Kind == TYPE_ERROR
+}
+
+// Failed returns true if the diagnostic tells a package or file could not be analysed at all.
+func (diagnostic *Diagnostic) Failed() bool {
+	return diagnostic.Kind == LIST_ERROR || diagnostic.Kind == ANALYSIS_ERROR
+}
+
+// addDiagnostic adds a diagnostic of kind about the file at filePath to the package.
+func (goPackage *GoPackage) addDiagnostic(kind DiagnosticKind, filePath string, err error) {
+	goPackage.Diagnostics = append(goPackage.Diagnostics, &Diagnostic{Kind: kind, FilePath: filePath, Message: err.Error()})
+}
+
+// newDiagnostics returns the diagnostics of the errors loading pkg. Compiler output reported by
+// the go command, starting with "# ", is left out if the errors are reported by the parser or
+// type checker too.
+func (config *Config) newDiagnostics(absDir string, pkg *packages.Package) []*Diagnostic {
+	checked := false
+	for _, pkgErr := range pkg.Errors {
+		checked = checked || pkgErr.Kind == packages.ParseError || pkgErr.Kind == packages.TypeError
+	}
+
+	var diagnostics []*Diagnostic
+	for _, pkgErr := range pkg.Errors {
+		if checked && pkgErr.Kind == packages.ListError && strings.HasPrefix(pkgErr.Msg, "# ") {
+			continue
+		}
+		diagnostics = append(diagnostics, config.newDiagnostic(absDir, pkgErr))
+	}
+	return diagnostics
+}
+
+// newDiagnostic returns the diagnostic of an error loading a package, located relative to the
+// analysed directory config.Dir, being absDir in absolute form.
+func (config *Config) newDiagnostic(absDir string, pkgErr packages.Error) *Diagnostic {
+	diagnostic := &Diagnostic{Kind: LIST_ERROR, Message: pkgErr.Msg}
+	switch pkgErr.Kind {
+	case packages.ParseError:
+		diagnostic.Kind = PARSE_ERROR
+	case packages.TypeError:
+		diagnostic.Kind = TYPE_ERROR
+	}
+
+	// The position is "file:line:col", "file:line", "" or "-", where the file may contain colons.
+	position := pkgErr.Pos
+	if position == "-" {
+		position = ""
+	}
+	var numbers []int
+	for len(numbers) < 2 {
+		colon := strings.LastIndex(position, ":")
+		if colon < 0 {
+			break
+		}
+		number, err := strconv.Atoi(position[colon+1:])
+		if err != nil {
+			break
+		}
+		numbers = append([]int{number}, numbers...)
+		position = position[:colon]
+	}
+	if len(numbers) > 0 {
+		diagnostic.Line = numbers[0]
+	}
+	if len(numbers) > 1 {
+		diagnostic.Column = numbers[1]
+	}
+	if position != "" {
+		if !filepath.IsAbs(position) {
+			position = filepath.Join(absDir, position)
+		}
+		diagnostic.FilePath = displayPath(config.Dir, absDir, position)
+	}
+	return diagnostic
+}
+
+// failedPackage returns the package pkg having errors but no files to analyse, like a
+// package that could not be found, so its diagnostics are reported.
+func (config *Config) failedPackage(absDir string, pkg *packages.Package) *GoPackage {
+	goPackage := &GoPackage{
+		Path:       pkg.PkgPath,
+		ImportPath: pkg.PkgPath,
+		Pack:       &ast.Package{Name: pkg.Name, Files: make(map[string]*ast.File)},
+		config:     config,
+		id:         pkg.ID,
+	}
+	if pkg.Dir != "" {
+		goPackage.Path = displayPath(config.Dir, absDir, pkg.Dir)
+	}
+	goPackage.Diagnostics = config.newDiagnostics(absDir, pkg)
+	return goPackage
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter_test
+
+import (
+	"go/ast"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+var diagnosticSources = map[string]string{
+	"typeerror/main.go": `package main
+
+func main() {
+	var x int = "text"
+	_ = x
+}
+`,
+	"parseerror/main.go": `package main
+
+func main() {
+	if {
+}
+`,
+	"valid/main.go": `package main
+
+import "os"
+
+func main() {
+	if len(os.Args) == 1 {
+	}
+}
+`,
+}
+
+// Errors loading a package are reported as diagnostics of the package, while the other
+// packages are still analysed.
+func TestDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, diagnosticSources)
+
+	goPackages, err := linter.AnalyzePackages(linter.NewConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(goPackages) != 3 {
+		t.Fatalf("Three packages should be analysed, and not %d!", len(goPackages))
+	}
+
+	expectedDiagnostics := []struct {
+		Kind   linter.DiagnosticKind
+		Line   int
+		Column int
+	}{
+		{Kind: linter.PARSE_ERROR, Line: 4, Column: 5},
+		{Kind: linter.TYPE_ERROR, Line: 4, Column: 14},
+	}
+	for i, expected := range expectedDiagnostics {
+		goPackage := goPackages[i]
+		if len(goPackage.Diagnostics) == 0 {
+			t.Fatalf("Package %s should have diagnostics!", goPackage.Path)
+		}
+		diagnostic := goPackage.Diagnostics[0]
+		if diagnostic.Kind != expected.Kind || diagnostic.Line != expected.Line || diagnostic.Column != expected.Column {
+			t.Errorf("Diagnostic should be %s at %d:%d, and not %s!", expected.Kind, expected.Line, expected.Column, diagnostic)
+		}
+		if diagnostic.FilePath != filepath.Join(goPackage.Path, "main.go") {
+			t.Errorf("Diagnostic should be located in %s, and not in %s!", filepath.Join(goPackage.Path, "main.go"), diagnostic.FilePath)
+		}
+	}
+
+	if valid := goPackages[2]; len(valid.Diagnostics) != 0 || len(valid.Violations) != 1 {
+		t.Errorf("Valid package should be analysed without diagnostics, but has %d diagnostics and %d files with violations!",
+			len(valid.Diagnostics), len(valid.Violations))
+	}
+}
+
+const CHECKER_PANICS linter.Rule = "CHECKER_PANICS"
+
+// A rule failing on files declaring triggerCheckerPanic, like a checker with a bug.
+func init() {
+	linter.Register(linter.NewChecker(linter.RuleInfo{
+		Rule:        CHECKER_PANICS,
+		Name:        "Checker panics",
+		Severity:    linter.INFO,
+		Description: "Test rule panicking on files declaring triggerCheckerPanic.",
+	}, func(goFile *linter.GoFile) {
+		goFile.Walk(func(node ast.Node) bool {
+			if funcDecl, ok := node.(*ast.FuncDecl); ok && funcDecl.Name.Name == "triggerCheckerPanic" {
+				panic("checker bug")
+			}
+			return true
+		})
+	}))
+}
+
+// Invalid code and failing checkers are reported as diagnostics, without ending the analysis.
+func TestPanicsAreDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	sources := map[string]string{
+		"malformednew/main.go": `package main
+
+func main() {
+	x := new()
+	m := new(map[string]int)
+	_, _ = x, m
+}
+`,
+		"checkerpanic/main.go": `package main
+
+func triggerCheckerPanic() {
+	m := new(map[string]int)
+	_ = m
+}
+`,
+	}
+	writeModule(t, dir, sources)
+
+	goPackages, err := linter.AnalyzePackages(linter.NewConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(goPackages) != 2 {
+		t.Fatalf("Two packages should be analysed, and not %d!", len(goPackages))
+	}
+	for _, goPackage := range goPackages {
+		violations := 0
+		for _, goFile := range goPackage.Violations {
+			for _, violation := range goFile.Violations {
+				if violation.Type == linter.MAP_ALLOCATED_WITH_NEW {
+					violations++
+				}
+			}
+		}
+		if violations != 1 {
+			t.Errorf("Package %s should have 1 MAP_ALLOCATED_WITH_NEW violation, not %d!", goPackage.Path, violations)
+		}
+
+		analysisErrors := 0
+		for _, diagnostic := range goPackage.Diagnostics {
+			if diagnostic.Kind == linter.ANALYSIS_ERROR {
+				analysisErrors++
+			}
+		}
+		if expected := map[string]int{"checkerpanic": 1, "malformednew": 0}[filepath.Base(goPackage.Path)]; analysisErrors != expected {
+			t.Errorf("Package %s should have %d ANALYSIS_ERROR diagnostics, not %d: %v!", goPackage.Path, expected,
+				analysisErrors, goPackage.Diagnostics)
+		}
+	}
+}
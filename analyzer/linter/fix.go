@@ -0,0 +1,244 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// SuggestedFix is a mechanical change resolving a violation, made of edits in the same file.
+type SuggestedFix struct {
+	Message   string
+	TextEdits []TextEdit
+}
+
+// TextEdit replaces the source code between the 0-based byte offsets StartOffset and
+// EndOffset with NewText, an empty NewText deletes the code.
+type TextEdit struct {
+	StartOffset int
+	EndOffset   int
+	NewText     string
+}
+
+// newTextEdit returns the edit replacing the source code from pos to end with newText.
+func (f *GoFile) newTextEdit(pos token.Pos, end token.Pos, newText string) TextEdit {
+	return TextEdit{
+		StartOffset: f.fileSet.Position(pos).Offset,
+		EndOffset:   f.fileSet.Position(end).Offset,
+		NewText:     newText,
+	}
+}
+
+// addFix suggests a fix made of textEdits for the violation.
+func (violation *Violation) addFix(message string, textEdits ...TextEdit) {
+	violation.SuggestedFixes = append(violation.SuggestedFixes, &SuggestedFix{Message: message, TextEdits: textEdits})
+}
+
+// hasComments returns true if there are comments inside node.
+func (f *GoFile) hasComments(node ast.Node) bool {
+	for _, commentGroup := range f.goFileNode.Comments {
+		if node.Pos() <= commentGroup.Pos() && commentGroup.End() <= node.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// fixStaticIf suggests a fix for the if statement with a constant condition to the violation,
+// keeping only the branch always taken. The parent is the if statement having ifStmt as else
+// branch, nil if there is none.
+func (f *GoFile) fixStaticIf(violation *Violation, ifStmt *ast.IfStmt, condition bool, parent *ast.IfStmt) {
+	switch {
+	case condition && ifStmt.Else == nil:
+		violation.addFix("Remove the condition", f.newTextEdit(ifStmt.Pos(), ifStmt.Body.Pos(), ""))
+	case condition:
+		violation.addFix("Remove the condition and the else branch",
+			f.newTextEdit(ifStmt.Pos(), ifStmt.Body.Pos(), ""),
+			f.newTextEdit(ifStmt.Body.End(), ifStmt.End(), ""))
+	case ifStmt.Else != nil:
+		violation.addFix("Remove the condition and the if branch", f.newTextEdit(ifStmt.Pos(), ifStmt.Else.Pos(), ""))
+	case parent != nil:
+		violation.addFix("Remove the else branch", f.newTextEdit(parent.Body.End(), ifStmt.End(), ""))
+	default:
+		violation.addFix("Remove the if statement", f.newTextEdit(ifStmt.Pos(), ifStmt.End(), ""))
+	}
+}
+
+// ApplyFixes applies the first suggested fix of each violation to the Go source code src,
+// returning the gofmt formatted result. Fixes overlapping a fix already applied are
+// skipped, running the analysis again on the result suggests them anew if still needed.
+func ApplyFixes(src []byte, violations []*Violation) ([]byte, error) {
+	var textEdits []TextEdit
+	for _, violation := range violations {
+		if len(violation.SuggestedFixes) > 0 && !overlaps(violation.SuggestedFixes[0].TextEdits, textEdits) {
+			textEdits = append(textEdits, violation.SuggestedFixes[0].TextEdits...)
+		}
+	}
+	if len(textEdits) == 0 {
+		return src, nil
+	}
+	return applyEdits(src, textEdits)
+}
+
+// ApplyFixes applies the suggested fixes of the violations in the file to its source code src
+// like ApplyFixes, but drops the fixes introducing type errors in the package, when the file
+// was type checked by the analysis. The next fix of a violation is tried if one is dropped.
+func (goFile *GoFile) ApplyFixes(src []byte) ([]byte, error) {
+	if goFile.typeChecker == nil {
+		return ApplyFixes(src, goFile.Violations)
+	}
+	var applied []*Violation
+	for _, violation := range goFile.Violations {
+		for _, fix := range violation.SuggestedFixes {
+			candidates := append(applied[:len(applied):len(applied)], &Violation{SuggestedFixes: []*SuggestedFix{fix}})
+			if fixed, err := ApplyFixes(src, candidates); err == nil && !goFile.typeChecker.introducesErrors(goFile.goFileNode, fixed) {
+				applied = candidates
+				break
+			}
+		}
+	}
+	return ApplyFixes(src, applied)
+}
+
+// ValidFixes returns the suggested fixes of the violation in the file not introducing type errors
+// in the package, or all of them if the file was not type checked by the analysis. The package
+// is type checked again for every fix, so fixes are only checked when offered. None are
+// returned if the source code of the file can not be read.
+func (goFile *GoFile) ValidFixes(violation *Violation) []*SuggestedFix {
+	if goFile.typeChecker == nil || len(violation.SuggestedFixes) == 0 {
+		return violation.SuggestedFixes
+	}
+	src, err := goFile.source()
+	if err != nil {
+		return nil
+	}
+	var fixes []*SuggestedFix
+	for _, fix := range violation.SuggestedFixes {
+		if fixed, err := applyEdits(src, fix.TextEdits); err == nil && !goFile.typeChecker.introducesErrors(goFile.goFileNode, fixed) {
+			fixes = append(fixes, fix)
+		}
+	}
+	return fixes
+}
+
+// applyEdits applies the non-overlapping textEdits to src, returning the gofmt formatted result.
+func applyEdits(src []byte, textEdits []TextEdit) ([]byte, error) {
+	textEdits = append([]TextEdit(nil), textEdits...)
+	sort.SliceStable(textEdits, func(i, j int) bool { return textEdits[i].StartOffset < textEdits[j].StartOffset })
+
+	var fixed []byte
+	offset := 0
+	for _, textEdit := range textEdits {
+		if textEdit.StartOffset < offset || textEdit.EndOffset < textEdit.StartOffset || textEdit.EndOffset > len(src) {
+			return nil, fmt.Errorf("invalid edit of offset %d to %d", textEdit.StartOffset, textEdit.EndOffset)
+		}
+		fixed = append(fixed, src[offset:textEdit.StartOffset]...)
+		fixed = append(fixed, textEdit.NewText...)
+		offset = textEdit.EndOffset
+	}
+	fixed = append(fixed, src[offset:]...)
+
+	return format.Source(fixed)
+}
+
+// overlaps returns true if any of the edits in a overlaps any of the edits in b.
+// Insertions at the same offset are considered overlapping, their order is ambiguous.
+func overlaps(a []TextEdit, b []TextEdit) bool {
+	for _, editA := range a {
+		for _, editB := range b {
+			if editA.StartOffset < editB.EndOffset && editB.StartOffset < editA.EndOffset ||
+				editA.StartOffset == editB.StartOffset {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeChecker type checks a package again with the source code of one of its files changed,
+// to find the fixes introducing type errors.
+type typeChecker struct {
+	fileSet *token.FileSet
+	pkg     *types.Package // Type checked package, providing its path and imports.
+	files   []*ast.File    // Syntax trees of all files in the package.
+	errors  map[string]int // Number of errors by message in the unchanged package, nil until checked.
+}
+
+// introducesErrors returns true if the package has errors not in the unchanged package, with
+// the source code of file changed to src. Files not in the package can not be checked.
+func (checker *typeChecker) introducesErrors(file *ast.File, src []byte) bool {
+	index := -1
+	for i, packageFile := range checker.files {
+		if packageFile == file {
+			index = i
+		}
+	}
+	if index < 0 {
+		return false
+	}
+	if checker.errors == nil {
+		checker.errors = checker.check(checker.files)
+	}
+
+	changedFile, err := parser.ParseFile(checker.fileSet, checker.fileSet.File(file.Pos()).Name(), src, parser.SkipObjectResolution)
+	if err != nil {
+		return true
+	}
+	files := append([]*ast.File(nil), checker.files...)
+	files[index] = changedFile
+	for message, count := range checker.check(files) {
+		if count > checker.errors[message] {
+			return true
+		}
+	}
+	return false
+}
+
+// check type checks the package made of files, returning the number of errors by message.
+// Imports are resolved to the packages imported when the package was loaded.
+func (checker *typeChecker) check(files []*ast.File) map[string]int {
+	imports := make(map[string]*types.Package)
+	for _, imported := range checker.pkg.Imports() {
+		imports[imported.Path()] = imported
+		if index := strings.LastIndex(imported.Path(), "/vendor/"); index >= 0 {
+			imports[imported.Path()[index+len("/vendor/"):]] = imported
+		}
+	}
+
+	errors := make(map[string]int)
+	config := &types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if path == "unsafe" {
+				return types.Unsafe, nil
+			}
+			if imported, ok := imports[path]; ok {
+				return imported, nil
+			}
+			return nil, fmt.Errorf("package %s is not imported", path)
+		}),
+		Error: func(err error) {
+			if typeError, ok := err.(types.Error); ok {
+				errors[typeError.Msg]++
+			} else {
+				errors[err.Error()]++
+			}
+		},
+	}
+	config.Check(checker.pkg.Path(), checker.fileSet, files, nil)
+	return errors
+}
+
+// importerFunc implements types.Importer with a function.
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+	"golang.org/x/tools/go/packages"
+)
+
+// fixFile applies the suggested fixes of the violations found in the only file with violations in dir.
+func fixFile(t *testing.T, dir string) string {
+	goPackages, err := linter.DetectViolations(linter.NewConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(goPackages) != 1 || len(goPackages[0].Violations) != 1 {
+		t.Fatalf("There should be one file with violations in %s!", dir)
+	}
+	goFile := goPackages[0].Violations[0]
+
+	src, err := ioutil.ReadFile(goFile.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed, err := goFile.ApplyFixes(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(fixed)
+}
+
+// fixSource applies the suggested fixes of the violations found in src, written to main.go in a
+// temporary directory.
+func fixSource(t *testing.T, src string) string {
+	dir := t.TempDir()
+	writeModule(t, dir, map[string]string{"main.go": src})
+	return fixFile(t, dir)
+}
+
+// typeErrors returns the number of type errors by message in the packages in and below dir,
+// with the files in overlay replaced by their content.
+func typeErrors(t *testing.T, dir string, overlay map[string][]byte) map[string]int {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes,
+		Dir:     dir,
+		Overlay: overlay,
+	}, "./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errors := make(map[string]int)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			errors[err.Msg]++
+		}
+	})
+	return errors
+}
+
+// Applying the suggested fixes to the testcode must not introduce type errors.
+func TestFixedTestcodeTypeChecks(t *testing.T) {
+	dirs, err := ioutil.ReadDir("./testcode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		dirPath, err := filepath.Abs(filepath.Join("./testcode", dir.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		goPackages, err := linter.DetectViolations(linter.NewConfig(dirPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		overlay := make(map[string][]byte)
+		for _, goPackage := range goPackages {
+			for _, goFile := range goPackage.Violations {
+				src, err := ioutil.ReadFile(goFile.FilePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if overlay[goFile.FilePath], err = goFile.ApplyFixes(src); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		originalErrors := typeErrors(t, dirPath, nil)
+		for message, count := range typeErrors(t, dirPath, overlay) {
+			if count > originalErrors[message] {
+				t.Errorf("Fixing %s should not introduce the type error: %s", dir.Name(), message)
+			}
+		}
+	}
+}
+
+func TestFixEmptyElseBody(t *testing.T) {
+	fixed := fixFile(t, "./testcode/emptyelsebody")
+	expected := "\tif randomInt == 50 {\n\t\tlog.Print(\"50\")\n\t}\n}\n"
+	if !strings.HasSuffix(fixed, expected) {
+		t.Errorf("Empty else should be removed, but fixed source is:\n%s", fixed)
+	}
+}
+
+func TestFixMapAllocatedWithNew(t *testing.T) {
+	fixed := fixSource(t, `package main
+
+import "log"
+
+func main() {
+	scores := new(map[string]int)
+	if scores == nil {
+		log.Fatal("No scores")
+	}
+}
+`)
+	if !strings.Contains(fixed, "scores := make(map[string]int)\n") {
+		t.Errorf("Map should be allocated with make(), but fixed source is:\n%s", fixed)
+	}
+}
+
+// The map is dereferenced, it can not be allocated with make() without changing its uses.
+func TestNoFixForDereferencedMap(t *testing.T) {
+	if fixed := fixFile(t, "./testcode/newmap"); !strings.Contains(fixed, "myMap := new(map[string]float64)\n") {
+		t.Errorf("Dereferenced map should not be fixed, but fixed source is:\n%s", fixed)
+	}
+}
+
+func TestFixReturnKillsCode(t *testing.T) {
+	fixed := fixSource(t, `package main
+
+import "log"
+
+func main() {
+	log.Println("Hello World")
+	return
+	log.Println("Finally done")
+}
+`)
+	if !strings.HasSuffix(fixed, "\tlog.Println(\"Hello World\")\n\treturn\n}\n") {
+		t.Errorf("Code after return should be removed, but fixed source is:\n%s", fixed)
+	}
+}
+
+// Removing the code after return leaves a variable unused, the fix is dropped.
+func TestNoFixIntroducingTypeErrors(t *testing.T) {
+	if fixed := fixFile(t, "./testcode/earlyreturn"); !strings.Contains(fixed, "\treturn\n\tlog.Printf(\"Finally done, a = %s\\n\", a)\n") {
+		t.Errorf("Code after return should be kept, but fixed source is:\n%s", fixed)
+	}
+}
+
+// Fixes are kept by the analysis, and only checked for type errors when offered.
+func TestValidFixes(t *testing.T) {
+	goPackages, err := linter.DetectViolations(linter.NewConfig("./testcode/earlyreturn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, goFile := range goPackages[0].Violations {
+		for _, violation := range goFile.Violations {
+			if violation.Type != linter.RETURN_KILLS_CODE {
+				continue
+			}
+			found = true
+			if len(violation.SuggestedFixes) != 1 {
+				t.Errorf("Violation should keep its suggested fix, but has %d", len(violation.SuggestedFixes))
+			}
+			if fixes := goFile.ValidFixes(violation); len(fixes) != 0 {
+				t.Errorf("Fix leaving a variable unused should not be valid, but got %d fixes", len(fixes))
+			}
+		}
+	}
+	if !found {
+		t.Error("There should be a RETURN_KILLS_CODE violation!")
+	}
+}
+
+func TestFixConditionEvaluatedStatically(t *testing.T) {
+	fixed := fixFile(t, "./testcode/staticconditions")
+	for _, expected := range []string{
+		"func main() {\n\t{\n\t\tlog.Println(\"Always true\")\n\t}\n\n\tif false {\n\t\tlog.Println(\"Not possible\")", // if true, if false and 1 >= 2.
+		"\tif true && 1 >= 0 || 0 >= 0 {\n\t\t",                    // 2 >= 1.
+		"\tif buf.Bytes == nil {\n\t\t// Not that one",             // Kept, buf and bytes would be unused.
+		"\t} else {\n\t\tlog.Println(\"Might be printed\")\n\t}\n", // else if true.
+	} {
+		if !strings.Contains(fixed, expected) {
+			t.Errorf("Fixed source should contain:\n%s\nFixed source is:\n%s", expected, fixed)
+		}
+	}
+}
+
+// Overlapping fixes are skipped, keeping the first one.
+func TestApplyFixesSkipsOverlappingFixes(t *testing.T) {
+	src := []byte("package main\n\nvar a, b = 1, 2\n")
+	violations := []*linter.Violation{
+		{SuggestedFixes: []*linter.SuggestedFix{{TextEdits: []linter.TextEdit{{StartOffset: 21, EndOffset: 22, NewText: "c"}}}}},
+		{SuggestedFixes: []*linter.SuggestedFix{{TextEdits: []linter.TextEdit{{StartOffset: 18, EndOffset: 22, NewText: "d"}}}}},
+		{SuggestedFixes: []*linter.SuggestedFix{{TextEdits: []linter.TextEdit{{StartOffset: 28, EndOffset: 29, NewText: "3"}}}}},
+	}
+
+	fixed, err := linter.ApplyFixes(src, violations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fixed) != "package main\n\nvar a, c = 1, 3\n" {
+		t.Errorf("Only the first and last fix should be applied, but fixed source is:\n%s", fixed)
+	}
+}
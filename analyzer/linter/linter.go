@@ -5,93 +5,60 @@ package linter
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"go/ast"
-	"go/importer"
 	"go/parser"
 	"go/token"
 	"go/types"
+	"io"
+	"io/ioutil"
 	"os"
-	"regexp"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
-	"bytes"
 	"github.com/chrisbbe/GoAnalysis/analyzer/linter/ccomplexity"
-	"io/ioutil"
-	"log"
-	"path/filepath"
-)
-
-const CC_LIMIT = 10 // Upper limit of cyclomatic complexity measures.
-const ERROR_OUTPUT_FILE = "GoAnalyzerError.log"
-
-var errorFileLogger *log.Logger
-
-// Set logging to file!.
-func init() {
-	errorLogFile, err := os.OpenFile(ERROR_OUTPUT_FILE, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
-	if err != nil {
-		panic(err)
-	}
-	errorFileLogger = log.New(errorLogFile, "", log.Lshortfile)
-}
-
-type Rule int
-
-//Rules.
-const (
-	RACE_CONDITION Rule = iota
-	FMT_PRINTING
-	STRING_CALLS_ITSELF
-	MAP_ALLOCATED_WITH_NEW
-	ERROR_IGNORED
-	EMPTY_IF_BODY
-	EMPTY_ELSE_BODY
-	EMPTY_FOR_BODY
-	GOTO_USED
-	CONDITION_EVALUATED_STATICALLY
-	BUFFER_NOT_FLUSHED
-	RETURN_KILLS_CODE
-	CYCLOMATIC_COMPLEXITY
 )
 
-var ruleStrings = map[Rule]string{
-	RACE_CONDITION:                 "RACE_CONDITION",
-	FMT_PRINTING:                   "FMT_PRINTING",
-	STRING_CALLS_ITSELF:            "STRING_CALLS_ITSELF",
-	MAP_ALLOCATED_WITH_NEW:         "MAP_ALLOCATED_WITH_NEW",
-	ERROR_IGNORED:                  "ERROR_IGNORED",
-	EMPTY_IF_BODY:                  "EMPTY_IF_BODY",
-	EMPTY_ELSE_BODY:                "EMPTY_ELSE_BODY",
-	EMPTY_FOR_BODY:                 "EMPTY_FOR_BODY",
-	GOTO_USED:                      "GOTO_USED",
-	CONDITION_EVALUATED_STATICALLY: "CONDITION_EVALUATED_STATICALLY",
-	BUFFER_NOT_FLUSHED:             "NO_BUFFERED_FLUSHING",
-	RETURN_KILLS_CODE:              "RETURN_KILLS_CODE",
-	CYCLOMATIC_COMPLEXITY:          "CYCLOMATIC_COMPLEXITY",
-}
+const CC_LIMIT = 10 // Default upper limit of cyclomatic complexity measures, configured by the 'limit' parameter.
 
 type GoFile struct {
 	FilePath        string
 	LinesOfCode     int
 	LinesOfComments int
+	BlankLines      int
 	Violations      []*Violation
 
-	goFileNode *ast.File
-	fileSet    *token.FileSet
-	typeInfo   *types.Info
-
-	typeErrorLogFile *os.File
+	goFileNode  *ast.File
+	fileSet     *token.FileSet
+	typeInfo    *types.Info
+	config      *Config
+	src         []byte                            // Source code, read when needed.
+	typeChecker *typeChecker                      // Type checks fixes, nil if the package can not be checked again.
+	complexity  []*ccomplexity.FunctionComplexity // Cyclomatic complexity of the functions, computed when needed.
+	rules       map[Rule]bool                     // Rules to check, all enabled rules if nil.
 }
 
 type GoPackage struct {
-	Violations []*GoFile
+	Violations  []*GoFile     // Files containing violations.
+	Files       []*GoFile     // All analysed files, sorted by path.
+	Diagnostics []*Diagnostic // Problems loading or analysing the package.
 
-	Path string
-	Pack *ast.Package
+	Path       string // Directory of the package.
+	ImportPath string
+	Pack       *ast.Package
 
-	fileSet  *token.FileSet
-	typeInfo *types.Info
+	fileSet     *token.FileSet
+	typeInfo    *types.Info
+	typeChecker *typeChecker
+	config      *Config
+	id          string // Package ID given by the go command, differing from ImportPath for test variants.
+	cacheKey    string // Key to cache the analysis result with, not cached if empty.
+	cached      bool   // Analysis result is taken from the cache.
 }
 
 func (goPackage *GoPackage) GetFileNodes() (goFiles []*ast.File) {
@@ -103,25 +70,26 @@ func (goPackage *GoPackage) GetFileNodes() (goFiles []*ast.File) {
 
 type Violation struct {
 	Type        Rule
+	Severity    Severity
 	Description string
-	SrcLine     int
-}
+	SrcLine     int // Same as StartLine.
 
-func (rule Rule) String() string {
-	return ruleStrings[rule]
-}
+	// Position of the offending node, lines and columns start at 1 and columns
+	// are counted in bytes. Offsets are the 0-based byte offsets in the file,
+	// EndOffset and EndColumn are just after the last character of the node.
+	StartLine   int
+	StartColumn int
+	EndLine     int
+	EndColumn   int
+	StartOffset int
+	EndOffset   int
 
-func (violation *Violation) String() string {
-	return fmt.Sprintf("%s (Line %d) - %s.", violation.Type, violation.SrcLine, violation.Description)
+	SuggestedFixes []*SuggestedFix `json:",omitempty"` // Mechanical fixes, the first is applied by ApplyFixes.
+	Builds         []string        // Build configurations the violation was found under.
 }
 
-// Marshal Rule string value instead of int value.
-func (rule Rule) MarshalText() ([]byte, error) {
-	var buffer bytes.Buffer
-	if _, err := buffer.WriteString(rule.String()); err != nil {
-		return buffer.Bytes(), err
-	}
-	return buffer.Bytes(), nil
+func (violation *Violation) String() string {
+	return fmt.Sprintf("%s (Line %d:%d) - %s.", violation.Type, violation.SrcLine, violation.StartColumn, violation.Description)
 }
 
 func isDirectory(path string) (bool, error) {
@@ -132,170 +100,300 @@ func isDirectory(path string) (bool, error) {
 	return fileInfo.IsDir(), err
 }
 
-func getAllDirectories(searchDir string) (map[string]map[string]*ast.Package, *token.FileSet) {
-	dirPackages := make(map[string]map[string]*ast.Package)
-	fileSet := token.NewFileSet()
+// DetectViolations analyses the Go source files in config.Dir with the rules enabled in
+// config, returning the packages containing violations.
+func DetectViolations(config *Config) (goPackageViolations []*GoPackage, err error) {
+	goPackages, err := AnalyzePackages(config)
+	if err != nil {
+		return goPackageViolations, err
+	}
+	return WithViolations(goPackages), nil
+}
 
-	err := filepath.Walk(searchDir, func(folderPath string, f os.FileInfo, err error) error {
-		if f.IsDir() {
-			pks, firstErr := parser.ParseDir(fileSet, folderPath, nil, parser.ParseComments)
-			if firstErr != nil {
-				return firstErr
-			}
-			dirPackages[folderPath] = pks
+// WithViolations returns the packages in goPackages containing violations.
+func WithViolations(goPackages []*GoPackage) (goPackageViolations []*GoPackage) {
+	for _, goPackage := range goPackages {
+		if len(goPackage.Violations) > 0 {
+			goPackageViolations = append(goPackageViolations, goPackage)
 		}
-		return nil
-	})
+	}
+	return goPackageViolations
+}
 
-	if err != nil {
-		log.Fatalf("Parsing error: %s", err)
+// WithFindings returns the packages in goPackages containing violations or diagnostics.
+func WithFindings(goPackages []*GoPackage) (goPackageFindings []*GoPackage) {
+	for _, goPackage := range goPackages {
+		if len(goPackage.Violations) > 0 || len(goPackage.Diagnostics) > 0 {
+			goPackageFindings = append(goPackageFindings, goPackage)
+		}
 	}
-	return dirPackages, fileSet
+	return goPackageFindings
 }
 
-func DetectViolations(goSourceDir string) (goPackageViolations []*GoPackage, err error) {
-	if isDir, err := isDirectory(goSourceDir); err == nil && !isDir {
-		return goPackageViolations, fmt.Errorf("%s is not a directory", goSourceDir)
+// AnalyzePackages analyses the Go source files in config.Dir with the rules enabled in
+// config, returning all packages sorted by path and name, also those without violations.
+// Packages are analysed once per build configuration, merging the results.
+func AnalyzePackages(config *Config) (goPackages []*GoPackage, err error) {
+	if isDir, err := isDirectory(config.Dir); err == nil && !isDir {
+		return goPackages, fmt.Errorf("%s is not a directory", config.Dir)
 	} else if err != nil {
-		return goPackageViolations, err
+		return goPackages, err
 	}
 
-	sourceDirPackages, fileSet := getAllDirectories(goSourceDir)
-
-	for path, pkgs := range sourceDirPackages {
-		for _, packNode := range pkgs {
-
-			goPackage := &GoPackage{
-				Path:    path,
-				Pack:    packNode,
-				fileSet: fileSet,
+	for _, buildConfig := range config.builds() {
+		buildPackages, err := loadPackages(config, buildConfig)
+		if err != nil {
+			return nil, err
+		}
+		analyzeConcurrently(buildPackages, config.jobs())
+		for _, goPackage := range buildPackages {
+			for _, goFile := range goPackage.Violations {
+				for _, violation := range goFile.Violations {
+					violation.Builds = []string{buildConfig.String()}
+				}
 			}
-
-			goPackage.measureCyclomaticComplexity(CC_LIMIT)
-			goPackage.detectBugsAndCodeSmells()
-
-			if len(goPackage.Violations) > 0 {
-				goPackageViolations = append(goPackageViolations, goPackage)
+			for _, diagnostic := range goPackage.Diagnostics {
+				diagnostic.Builds = []string{buildConfig.String()}
 			}
 		}
+		goPackages = mergePackages(goPackages, buildPackages)
 	}
 
-	return goPackageViolations, nil
+	sort.Slice(goPackages, func(i, j int) bool {
+		if goPackages[i].Path != goPackages[j].Path {
+			return goPackages[i].Path < goPackages[j].Path
+		}
+		return goPackages[i].Pack.Name < goPackages[j].Pack.Name
+	})
+	return goPackages, nil
+}
+
+// analyzeConcurrently analyses goPackages using jobs workers. Every package is analysed by a
+// single worker, so results do not depend on the scheduling.
+func analyzeConcurrently(goPackages []*GoPackage, jobs int) {
+	packageQueue := make(chan *GoPackage)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for goPackage := range packageQueue {
+				if !goPackage.cached {
+					goPackage.analyzeRecovering()
+					if err := goPackage.storeResult(); err != nil {
+						goPackage.addDiagnostic(ANALYSIS_ERROR, "", fmt.Errorf("caching the result: %s", err))
+					}
+				}
+			}
+		}()
+	}
+	for _, goPackage := range goPackages {
+		packageQueue <- goPackage
+	}
+	close(packageQueue)
+	wg.Wait()
 }
 
-// countLinesInFile counts number of lines which are code and comments and returns the result.
-func (goFile *GoFile) countLinesInFile() {
-	file, err := os.Open(goFile.FilePath)
-	if err != nil {
-		panic(err)
-	}
-	// Close file after reading!
+// analyzeRecovering analyses the package, reporting a panic as an ANALYSIS_ERROR diagnostic of the
+// package instead of ending the process.
+func (goPackage *GoPackage) analyzeRecovering() {
 	defer func() {
-		if err := file.Close(); err != nil {
-			panic(err)
+		if r := recover(); r != nil {
+			goPackage.addDiagnostic(ANALYSIS_ERROR, "", fmt.Errorf("analysis failed: %v", r))
 		}
 	}()
+	goPackage.Analyze()
+}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		if len(scanner.Text()) > 0 {
-			line := strings.TrimSpace(scanner.Text())
-			if strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*") || strings.HasPrefix(line, "*/") {
-				goFile.LinesOfComments++
-			} else {
-				goFile.LinesOfCode++
-			}
-		}
+// jobs returns the number of packages to analyse concurrently.
+func (config *Config) jobs() int {
+	if config.Jobs < 1 {
+		return runtime.GOMAXPROCS(0)
 	}
+	return config.Jobs
 }
 
-func (goPackage *GoPackage) measureCyclomaticComplexity(upperLimit int) error {
-	for filePath, goFile := range goPackage.Pack.Files {
-		srcFile, err := ioutil.ReadFile(filePath)
+// source returns the source code of the file, its overlay content if it has one.
+func (goFile *GoFile) source() ([]byte, error) {
+	if goFile.src != nil {
+		return goFile.src, nil
+	}
+	if absPath, err := filepath.Abs(goFile.FilePath); err == nil && goFile.config != nil && goFile.config.Overlay[absPath] != nil {
+		goFile.src = goFile.config.Overlay[absPath]
+		return goFile.src, nil
+	}
+	src, err := ioutil.ReadFile(goFile.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	goFile.src = src
+	return src, nil
+}
+
+// countLinesInFile counts number of lines which are code, comments and blank, in the overlay
+// content of the file if it has one.
+func (goFile *GoFile) countLinesInFile() error {
+	var src io.Reader
+	if absPath, err := filepath.Abs(goFile.FilePath); err == nil && goFile.config.Overlay[absPath] != nil {
+		src = bytes.NewReader(goFile.config.Overlay[absPath])
+	} else {
+		file, err := os.Open(goFile.FilePath)
 		if err != nil {
 			return err
 		}
+		defer file.Close()
+		src = file
+	}
 
-		complexity, err := ccomplexity.GetCyclomaticComplexityFunctionLevel(filePath, srcFile)
-		if err != nil {
-			return err
+	scanner := bufio.NewScanner(src)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			goFile.BlankLines++
+		} else if strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*") || strings.HasPrefix(line, "*/") {
+			goFile.LinesOfComments++
+		} else {
+			goFile.LinesOfCode++
 		}
+	}
+	return scanner.Err()
+}
 
-		for _, funCC := range complexity {
-			if funCC.Complexity > upperLimit {
+func (goPackage *GoPackage) Analyze() {
+	filePaths := make([]string, 0, len(goPackage.Pack.Files))
+	for filePath := range goPackage.Pack.Files {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
 
-				goFile := &GoFile{
-					FilePath:   filePath,
-					goFileNode: goFile,
-				}
-				goFile.countLinesInFile()
-
-				goFile.Violations = append(goFile.Violations, &Violation{
-					Type:    CYCLOMATIC_COMPLEXITY,
-					SrcLine: funCC.SrcLine,
-					Description: fmt.Sprintf("Cyclomatic complexity in %s() is %d, upper limit is %d.",
-						funCC.Name, funCC.Complexity, upperLimit),
-				})
-				goPackage.Violations = append(goPackage.Violations, goFile)
+	for _, filePath := range filePaths {
+		goFile := &GoFile{
+			FilePath:    filePath,
+			goFileNode:  goPackage.Pack.Files[filePath],
+			typeInfo:    goPackage.typeInfo,
+			fileSet:     goPackage.fileSet,
+			config:      goPackage.config,
+			typeChecker: goPackage.typeChecker,
+		}
+
+		if err := goFile.Analyse(); err != nil {
+			goPackage.addDiagnostic(ANALYSIS_ERROR, filePath, err)
+		}
+		if err := goFile.countLinesInFile(); err != nil {
+			goPackage.addDiagnostic(ANALYSIS_ERROR, filePath, err)
+		}
+		goPackage.Files = append(goPackage.Files, goFile)
+
+		if len(goFile.Violations) > 0 {
+			goPackage.Violations = append(goPackage.Violations, goFile)
+		}
+	}
+}
+
+// Analyse fires off all registered checkers enabled in the configuration on the goFile,
+// and removes the violations suppressed by @SuppressRule directives. Checkers panicking are
+// returned as errors, the violations of the other checkers are still found.
+func (goFile *GoFile) Analyse() error {
+	var errs []error
+	for _, checker := range Checkers() {
+		if goFile.checksRule(checker.Info().Rule) {
+			if err := goFile.check(checker); err != nil {
+				errs = append(errs, err)
 			}
 		}
 	}
+	goFile.applySuppressions()
+	return errors.Join(errs...)
+}
+
+// check runs checker on the goFile, returning a panic as an error.
+func (goFile *GoFile) check(checker Checker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("checking rule %s failed: %v", checker.Info().Rule, r)
+		}
+	}()
+	checker.Check(goFile)
 	return nil
 }
 
-func (goPackage *GoPackage) detectBugsAndCodeSmells() error {
-	conf := types.Config{
-		Importer:                 importer.Default(),
-		FakeImportC:              true,
-		DisableUnusedImportCheck: true,
-	}
+// checksRule returns true if the rule is enabled and among the rules to check in the file.
+func (goFile *GoFile) checksRule(rule Rule) bool {
+	return goFile.config.RuleEnabled(rule) && (goFile.rules == nil || goFile.rules[rule])
+}
 
-	goPackage.typeInfo = &types.Info{
-		Types: make(map[ast.Expr]types.TypeAndValue),
-		Uses:  make(map[*ast.Ident]types.Object),
+// CheckFile analyses the parsed and type checked file of pkg, made of files, like DetectViolations,
+// with the rules enabled in config among rules, or all enabled rules if none are given. It lets
+// other drivers, like go/analysis passes, reuse the checkers. Nil is returned if config excludes
+// the file. The error tells which checkers failed, the file holds the violations of the others.
+func CheckFile(config *Config, fileSet *token.FileSet, file *ast.File, pkg *types.Package, files []*ast.File,
+	typeInfo *types.Info, rules ...Rule) (*GoFile, error) {
+	filePath := fileSet.File(file.Pos()).Name()
+	if !config.FileIncluded(filePath) || config.parentDirExcluded(filePath) {
+		return nil, nil
 	}
-
-	if _, err := conf.Check(goPackage.Pack.Name, goPackage.fileSet, goPackage.GetFileNodes(), goPackage.typeInfo); err != nil {
-		errorFileLogger.Printf("Error: %s", err)
+	goFile := &GoFile{
+		FilePath:   filePath,
+		goFileNode: file,
+		fileSet:    fileSet,
+		typeInfo:   typeInfo,
+		config:     config,
+	}
+	if pkg != nil {
+		goFile.typeChecker = &typeChecker{fileSet: fileSet, pkg: pkg, files: files}
+	}
+	if len(rules) > 0 {
+		goFile.rules = make(map[Rule]bool)
+		for _, rule := range rules {
+			goFile.rules[rule] = true
+		}
 	}
+	err := goFile.Analyse()
+	return goFile, err
+}
 
-	goPackage.Analyze()
+// File returns the parsed syntax tree of the file, nil if it can not be read.
+func (goFile *GoFile) File() *ast.File {
+	goFile.parse()
+	return goFile.goFileNode
+}
 
-	return nil
+// FileSet returns the file set the file was parsed with, used to resolve token positions.
+func (goFile *GoFile) FileSet() *token.FileSet {
+	goFile.parse()
+	return goFile.fileSet
 }
 
-func (goPackage *GoPackage) Analyze() {
-	for filePath, file := range goPackage.Pack.Files {
-		goFile := &GoFile{
-			FilePath:   filePath,
-			goFileNode: file,
-			typeInfo:   goPackage.typeInfo,
-			fileSet:    goPackage.fileSet,
-		}
+// parse parses the file if its analysis result was taken from the cache, without syntax tree.
+func (goFile *GoFile) parse() {
+	if goFile.fileSet != nil {
+		return
+	}
+	goFile.fileSet = token.NewFileSet()
+	// Errors were reported when the file was analysed, the syntax tree is as complete as it can be.
+	goFile.goFileNode, _ = parser.ParseFile(goFile.fileSet, goFile.FilePath, nil, parser.ParseComments)
+}
 
-		goFile.Analyse()
+// Config returns the configuration the file is analysed with, holding the rule parameters.
+func (goFile *GoFile) Config() *Config {
+	return goFile.config
+}
 
-		if len(goFile.Violations) > 0 {
-			goFile.countLinesInFile()
-			goPackage.Violations = append(goPackage.Violations, goFile)
-		}
+// Complexity returns the cyclomatic complexity of the functions in the file, computed from
+// the syntax tree of the analysis on first use.
+func (goFile *GoFile) Complexity() []*ccomplexity.FunctionComplexity {
+	if goFile.complexity == nil && goFile.File() != nil {
+		goFile.complexity = ccomplexity.GetCyclomaticComplexityOfFile(goFile.fileSet, goFile.goFileNode)
 	}
+	if goFile.complexity == nil {
+		goFile.complexity = []*ccomplexity.FunctionComplexity{} // No functions, do not compute again.
+	}
+	return goFile.complexity
 }
 
-// Analyse fires off all detection algorithms on the goFile.
-func (goFile *GoFile) Analyse() {
-	goFile.detectFmtPrinting()
-	goFile.detectMapsAllocatedWithNew()
-	goFile.detectEmptyIfBody()
-	goFile.detectEmptyElseBody()
-	goFile.detectEmptyForBody()
-	goFile.detectGoToStatements()
-	goFile.detectRaceInGoRoutine()
-	goFile.detectIgnoredErrors()
-	goFile.detectStaticCondition()
-	goFile.detectRecursiveStringMethods()
-	goFile.detectReturnKillingCode()
-	goFile.detectBufferNotFlushed()
+// TypeInfo returns the type information of the package the file belongs to.
+func (goFile *GoFile) TypeInfo() *types.Info {
+	return goFile.typeInfo
 }
 
 type walker func(ast.Node) bool
@@ -307,26 +405,12 @@ func (w walker) Visit(node ast.Node) ast.Visitor {
 	return nil
 }
 
-func (f *GoFile) walk(fn func(ast.Node) bool) {
+// Walk traverses the syntax tree of the file in depth-first order, children
+// of a node are only visited if fn returns true.
+func (f *GoFile) Walk(fn func(ast.Node) bool) {
 	ast.Walk(walker(fn), f.goFileNode)
 }
 
-// ruleIgnored inspects the commentGroup after a @SuppressRule("RULE_NAME") annotation
-// corresponding to the ruleToIgnore specified. Return TRUE if found, ELSE otherwise.
-func ruleIgnored(ruleToIgnore Rule, commentGroup *ast.CommentGroup) bool {
-	suppressRule := regexp.MustCompile(`@SuppressRule\("(?P<Rule>\S+)"\)`)
-	if commentGroup != nil {
-		for _, comment := range commentGroup.List {
-			if result := suppressRule.FindStringSubmatch(comment.Text); len(result) > 0 {
-				if result[1] == ruleToIgnore.String() {
-					return true
-				}
-			}
-		}
-	}
-	return false
-}
-
 // validateParams checks that all statements in function body is referencing
 // local variable (including function argument), and not referencing outer scope.
 func validateParams(exprStmt *ast.ExprStmt, List []*ast.Field) bool {
@@ -364,40 +448,57 @@ func getSourceCodeLineNumber(fileSet *token.FileSet, position token.Pos) int {
 	return fileSet.File(position).Line(position)
 }
 
-// AddViolation adds a new violation as specified trough its argument to the GoFile list of violations.
-func (f *GoFile) AddViolation(tokPosition token.Pos, violationType Rule, description string) *Violation {
-	srcLine := getSourceCodeLineNumber(f.fileSet, tokPosition)
+// AddViolation adds a new violation as specified trough its argument to the GoFile list of violations,
+// located at the source code range of the offending node.
+func (f *GoFile) AddViolation(node ast.Node, violationType Rule, description string) *Violation {
+	start, end := f.fileSet.Position(node.Pos()), f.fileSet.Position(node.End())
 	violation := &Violation{
 		Type:        violationType,
+		Severity:    f.config.RuleSeverity(violationType),
 		Description: description,
-		SrcLine:     srcLine,
+		SrcLine:     start.Line,
+		StartLine:   start.Line,
+		StartColumn: start.Column,
+		EndLine:     end.Line,
+		EndColumn:   end.Column,
+		StartOffset: start.Offset,
+		EndOffset:   end.Offset,
 	}
 	f.Violations = append(f.Violations, violation)
 	return violation
 }
 
+// Detect violations of rule: CYCLOMATIC_COMPLEXITY.
+func (goFile *GoFile) detectHighCyclomaticComplexity() {
+	upperLimit := goFile.config.IntParam(CYCLOMATIC_COMPLEXITY, "limit", CC_LIMIT)
+	for _, funCC := range goFile.Complexity() {
+		if funCC.Complexity > upperLimit {
+			goFile.AddViolation(
+				funCC.Function,
+				CYCLOMATIC_COMPLEXITY,
+				fmt.Sprintf("Cyclomatic complexity in %s() is %d, upper limit is %d.", funCC.Name, funCC.Complexity, upperLimit),
+			)
+		}
+	}
+}
+
 // Detect violations of rule: FMT_PRINTING.
 func (goFile *GoFile) detectFmtPrinting() {
-	ignored := false
-	goFile.walk(func(node ast.Node) bool {
+	goFile.Walk(func(node ast.Node) bool {
 		fmtMethods := []string{"Print", "Println", "Printf"}
 
 		switch t := node.(type) {
-		case *ast.FuncDecl:
-			ignored = ruleIgnored(FMT_PRINTING, t.Doc)
 		case *ast.SelectorExpr:
-			if !ignored {
-				if packName, ok := t.X.(*ast.Ident); ok {
-					for _, method := range fmtMethods {
-						if packName.Name == "fmt" && t.Sel.Name == method {
-							goFile.AddViolation(
-								t.Pos(),
-								FMT_PRINTING,
-								fmt.Sprint("Printing from the fmt package are not synchronized and usually intended for"+
-									" debugging purposes. Consider to use the log package!"),
-							)
-							return false
-						}
+			if packName, ok := t.X.(*ast.Ident); ok {
+				for _, method := range fmtMethods {
+					if packName.Name == "fmt" && t.Sel.Name == method {
+						goFile.AddViolation(
+							t,
+							FMT_PRINTING,
+							fmt.Sprint("Printing from the fmt package are not synchronized and usually intended for"+
+								" debugging purposes. Consider to use the log package!"),
+						)
+						return false
 					}
 				}
 			}
@@ -408,20 +509,39 @@ func (goFile *GoFile) detectFmtPrinting() {
 
 // Detect violations of rule: MAP_ALLOCATED_WITH_NEW.
 func (goFile *GoFile) detectMapsAllocatedWithNew() {
-	goFile.walk(func(node ast.Node) bool {
+	variables := make(map[ast.Expr]*ast.Ident) // Variables assigned by expressions.
+	goFile.Walk(func(node ast.Node) bool {
 		newKeyword := "new"
 
 		switch t := node.(type) {
+		case *ast.AssignStmt:
+			if len(t.Lhs) == len(t.Rhs) {
+				for i, lhs := range t.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						variables[t.Rhs[i]] = ident
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			if len(t.Names) == len(t.Values) {
+				for i, name := range t.Names {
+					variables[t.Values[i]] = name
+				}
+			}
 		case *ast.CallExpr:
 			if value, ok := t.Fun.(*ast.Ident); ok {
-				if ok && value.Name == newKeyword {
+				if ok && value.Name == newKeyword && len(t.Args) == 1 {
 					if _, ok := t.Args[0].(*ast.MapType); ok {
-						goFile.AddViolation(
-							t.Pos(),
+						violation := goFile.AddViolation(
+							t,
 							MAP_ALLOCATED_WITH_NEW,
 							fmt.Sprint("Maps must be initialized with make(), new() allocates a nil map causing runtime "+
 								"panic on write operations!"),
 						)
+						if variable, ok := variables[t]; ok && !goFile.usedAsPointer(variable) {
+							violation.addFix("Allocate the map with make(), the result is a map and not a pointer to a map",
+								goFile.newTextEdit(value.Pos(), value.End(), "make"))
+						}
 						return false
 					}
 				}
@@ -431,13 +551,40 @@ func (goFile *GoFile) detectMapsAllocatedWithNew() {
 	})
 }
 
+// usedAsPointer returns true if the variable is dereferenced or passed to a function in the
+// file, or if its uses are unknown without type information.
+func (goFile *GoFile) usedAsPointer(variable *ast.Ident) bool {
+	if goFile.typeInfo == nil || goFile.typeInfo.ObjectOf(variable) == nil {
+		return true
+	}
+	object := goFile.typeInfo.ObjectOf(variable)
+	isVariable := func(expr ast.Expr) bool {
+		ident, ok := ast.Unparen(expr).(*ast.Ident)
+		return ok && goFile.typeInfo.Uses[ident] == object
+	}
+
+	used := false
+	goFile.Walk(func(node ast.Node) bool {
+		switch t := node.(type) {
+		case *ast.StarExpr:
+			used = used || isVariable(t.X)
+		case *ast.CallExpr:
+			for _, arg := range t.Args {
+				used = used || isVariable(arg)
+			}
+		}
+		return !used
+	})
+	return used
+}
+
 // Detect violations of rule: EMPTY_IF_BODY.
 func (goFile *GoFile) detectEmptyIfBody() {
-	goFile.walk(func(node ast.Node) bool {
+	goFile.Walk(func(node ast.Node) bool {
 		if ifStmt, ok := node.(*ast.IfStmt); ok {
 			if len(ifStmt.Body.List) == 0 {
 				goFile.AddViolation(
-					ifStmt.Pos(),
+					ifStmt,
 					EMPTY_IF_BODY,
 					fmt.Sprint("If body is empty, wasteful to not do anything with the if condition."),
 				)
@@ -450,15 +597,18 @@ func (goFile *GoFile) detectEmptyIfBody() {
 
 // Detect violations of rule: EMPTY_ELSE_BODY.
 func (goFile *GoFile) detectEmptyElseBody() {
-	goFile.walk(func(node ast.Node) bool {
+	goFile.Walk(func(node ast.Node) bool {
 		if ifStmt, ok := node.(*ast.IfStmt); ok {
 			if elseBody, ok := ifStmt.Else.(*ast.BlockStmt); ok {
 				if len(elseBody.List) == 0 {
-					goFile.AddViolation(
-						elseBody.Pos(),
+					violation := goFile.AddViolation(
+						elseBody,
 						EMPTY_ELSE_BODY,
 						fmt.Sprint("ELse body is empty, wasteful to not do anything with the else condition."),
 					)
+					if !goFile.hasComments(elseBody) {
+						violation.addFix("Remove the empty else", goFile.newTextEdit(ifStmt.Body.End(), elseBody.End(), ""))
+					}
 					return false
 				}
 			}
@@ -469,11 +619,11 @@ func (goFile *GoFile) detectEmptyElseBody() {
 
 // Detect violations of rule: EMPTY_FOR_BODY.
 func (goFile *GoFile) detectEmptyForBody() {
-	goFile.walk(func(node ast.Node) bool {
+	goFile.Walk(func(node ast.Node) bool {
 		if forStmt, ok := node.(*ast.ForStmt); ok {
 			if len(forStmt.Body.List) == 0 {
 				goFile.AddViolation(
-					forStmt.Pos(),
+					forStmt,
 					EMPTY_FOR_BODY,
 					fmt.Sprint("For body is empty, wasteful to not do anything with the for condition."),
 				)
@@ -486,11 +636,11 @@ func (goFile *GoFile) detectEmptyForBody() {
 
 // Detect violations of rule: GOTO_USED.
 func (goFile *GoFile) detectGoToStatements() {
-	goFile.walk(func(node ast.Node) bool {
+	goFile.Walk(func(node ast.Node) bool {
 		if branchStmt, ok := node.(*ast.BranchStmt); ok {
 			if branchStmt.Label != nil {
 				goFile.AddViolation(
-					branchStmt.Pos(),
+					branchStmt,
 					GOTO_USED,
 					fmt.Sprint("Please dont use GOTO statements, they lead to spagehetti code!"),
 				)
@@ -503,7 +653,7 @@ func (goFile *GoFile) detectGoToStatements() {
 
 // Detect violations of rule: RACE_CONDITION.
 func (goFile *GoFile) detectRaceInGoRoutine() {
-	goFile.walk(func(node ast.Node) bool {
+	goFile.Walk(func(node ast.Node) bool {
 		if goStmt, ok := node.(*ast.GoStmt); ok {
 
 			if goFunc, ok := goStmt.Call.Fun.(*ast.FuncLit); ok {
@@ -514,7 +664,7 @@ func (goFile *GoFile) detectRaceInGoRoutine() {
 
 						if !validateParams(exprStmt, goFuncParams) {
 							goFile.AddViolation(
-								node.Pos(),
+								node,
 								RACE_CONDITION,
 								fmt.Sprint("Loop iterator variables must be passed as argument to Goroutine, not referenced."),
 							)
@@ -531,7 +681,7 @@ func (goFile *GoFile) detectRaceInGoRoutine() {
 
 // Detect violations of rule: RETURN_KILLS_CODE.
 func (goFile *GoFile) detectReturnKillingCode() {
-	goFile.walk(func(node ast.Node) bool {
+	goFile.Walk(func(node ast.Node) bool {
 
 		if funcDecl, ok := node.(*ast.FuncDecl); ok {
 			if funcDecl.Body != nil {
@@ -539,12 +689,14 @@ func (goFile *GoFile) detectReturnKillingCode() {
 				for index, bodyStmt := range funcDecl.Body.List {
 
 					if _, ok := bodyStmt.(*ast.ReturnStmt); ok && bodyLength > index {
-						goFile.AddViolation(
-							bodyStmt.Pos(),
+						violation := goFile.AddViolation(
+							bodyStmt,
 							RETURN_KILLS_CODE,
 							fmt.Sprint("Code is dead because of return! There is no possible execution path to the code below in "+
 								"this scope!"),
 						)
+						violation.addFix("Remove the unreachable code", goFile.newTextEdit(bodyStmt.End(),
+							funcDecl.Body.List[bodyLength].End(), ""))
 						return false
 					}
 				}
@@ -557,15 +709,11 @@ func (goFile *GoFile) detectReturnKillingCode() {
 // Detect violations of rule: ERROR_IGNORE.
 func (goFile *GoFile) detectIgnoredErrors() {
 	errorType := "error"
-	ignored := false
 	var returnResults []ast.Expr
 	var rightHandSideCallExpr []*ast.CallExpr // Holds CallExpr taken part in AssignStmt, avoid checking these CallExpr.
 
-	goFile.walk(func(node ast.Node) bool {
+	goFile.Walk(func(node ast.Node) bool {
 		switch t := node.(type) {
-		case *ast.FuncDecl:
-			ignored = ruleIgnored(ERROR_IGNORED, t.Doc)
-
 		case *ast.ReturnStmt:
 			// Hold the list of return result expressions.
 			returnResults = t.Results
@@ -576,27 +724,25 @@ func (goFile *GoFile) detectIgnoredErrors() {
 				rightHandSideCallExpr = append(rightHandSideCallExpr, callExpr)
 			}
 
-			if !ignored {
-				var ignoredReturnIndex []int
-				for index, expr := range t.Lhs {
-					if varName, ok := expr.(*ast.Ident); ok {
-						if varName.Name == "_" {
-							ignoredReturnIndex = append(ignoredReturnIndex, index)
-						}
+			var ignoredReturnIndex []int
+			for index, expr := range t.Lhs {
+				if varName, ok := expr.(*ast.Ident); ok {
+					if varName.Name == "_" {
+						ignoredReturnIndex = append(ignoredReturnIndex, index)
 					}
 				}
-				if len(ignoredReturnIndex) != 0 {
-					if tv, ok := goFile.typeInfo.Types[t.Rhs[0]]; ok {
-						if tuple, ok := tv.Type.(*types.Tuple); ok {
-							for _, returnIndex := range ignoredReturnIndex {
-								if returnIndex <= tuple.Len() && tuple.At(returnIndex).Type().String() == errorType {
-									goFile.AddViolation(
-										t.Pos(),
-										ERROR_IGNORED,
-										fmt.Sprint("Never ignore erros, ignoring them can lead to program crashes"),
-									)
-									return false
-								}
+			}
+			if len(ignoredReturnIndex) != 0 {
+				if tv, ok := goFile.typeInfo.Types[t.Rhs[0]]; ok {
+					if tuple, ok := tv.Type.(*types.Tuple); ok {
+						for _, returnIndex := range ignoredReturnIndex {
+							if returnIndex <= tuple.Len() && tuple.At(returnIndex).Type().String() == errorType {
+								goFile.AddViolation(
+									t,
+									ERROR_IGNORED,
+									fmt.Sprint("Never ignore erros, ignoring them can lead to program crashes"),
+								)
+								return false
 							}
 						}
 					}
@@ -605,48 +751,46 @@ func (goFile *GoFile) detectIgnoredErrors() {
 			return true
 
 		case *ast.CallExpr:
-			if !ignored {
-				// Loop through return result expression to check whether CallExpr is part of return.
-				// Flagging errors not assigned to variable in return statement is wrong!
-				for _, returnResult := range returnResults {
-					if returnCallExpr, ok := returnResult.(*ast.CallExpr); ok {
-						if returnCallExpr == t {
-							// Call Expression is in return, stop looking after calls on functions returning error.
-							return false
-						}
+			// Loop through return result expression to check whether CallExpr is part of return.
+			// Flagging errors not assigned to variable in return statement is wrong!
+			for _, returnResult := range returnResults {
+				if returnCallExpr, ok := returnResult.(*ast.CallExpr); ok {
+					if returnCallExpr == t {
+						// Call Expression is in return, stop looking after calls on functions returning error.
+						return false
 					}
 				}
+			}
 
-				// We don't want to check CallExpressions that is part of AssignStmt.
-				for _, rhsCallExpr := range rightHandSideCallExpr {
-					if t == rhsCallExpr {
-						return false
-					}
+			// We don't want to check CallExpressions that is part of AssignStmt.
+			for _, rhsCallExpr := range rightHandSideCallExpr {
+				if t == rhsCallExpr {
+					return false
 				}
+			}
 
-				// CallExpr is not part of return result, check further!
-				if tv, ok := goFile.typeInfo.Types[t]; ok {
-					if name, ok := tv.Type.(*types.Named); ok {
+			// CallExpr is not part of return result, check further!
+			if tv, ok := goFile.typeInfo.Types[t]; ok {
+				if name, ok := tv.Type.(*types.Named); ok {
 
-						if name.String() == errorType {
+					if name.String() == errorType {
+						goFile.AddViolation(
+							t,
+							ERROR_IGNORED,
+							fmt.Sprint("Never ignore erros, ignoring them can lead to program crashes"),
+						)
+						return false
+					}
+				} else if tuple, ok := tv.Type.(*types.Tuple); ok {
+					for i := 0; i < tuple.Len(); i++ {
+						if tuple.At(i).Type().String() == errorType {
 							goFile.AddViolation(
-								t.Pos(),
+								t,
 								ERROR_IGNORED,
 								fmt.Sprint("Never ignore erros, ignoring them can lead to program crashes"),
 							)
 							return false
 						}
-					} else if tuple, ok := tv.Type.(*types.Tuple); ok {
-						for i := 0; i < tuple.Len(); i++ {
-							if tuple.At(i).Type().String() == errorType {
-								goFile.AddViolation(
-									t.Pos(),
-									ERROR_IGNORED,
-									fmt.Sprint("Never ignore erros, ignoring them can lead to program crashes"),
-								)
-								return false
-							}
-						}
 					}
 				}
 			}
@@ -668,18 +812,25 @@ func (goFile *GoFile) detectStaticCondition() {
 		token.NEQ:  true, // !=
 	}
 	var lastViolation *Violation
+	elseIfParent := make(map[*ast.IfStmt]*ast.IfStmt) // If statements in else branches, mapped to the if they belong to.
 
-	goFile.walk(func(node ast.Node) bool {
+	goFile.Walk(func(node ast.Node) bool {
 		switch t := node.(type) {
 
 		case *ast.IfStmt:
+			if elseIf, ok := t.Else.(*ast.IfStmt); ok {
+				elseIfParent[elseIf] = t
+			}
 			if cond, ok := t.Cond.(*ast.Ident); ok && (cond.Name == "true" || cond.Name == "false") {
 				// Catch obvious static conditions.
 				lastViolation = goFile.AddViolation(
-					t.Pos(),
+					t,
 					CONDITION_EVALUATED_STATICALLY,
 					fmt.Sprintf("Condition will always be %s", cond),
 				)
+				if t.Init == nil {
+					goFile.fixStaticIf(lastViolation, t, cond.Name == "true", elseIfParent[t])
+				}
 			}
 
 		case *ast.BinaryExpr:
@@ -690,10 +841,14 @@ func (goFile *GoFile) detectStaticCondition() {
 						// where x,y and k is basic literals.
 						if lastViolation != nil && lastViolation.SrcLine != getSourceCodeLineNumber(goFile.fileSet, t.Pos()) {
 							lastViolation = goFile.AddViolation(
-								t.Pos(),
+								t,
 								CONDITION_EVALUATED_STATICALLY,
 								fmt.Sprint("Both left and right operand is basic literal that can be eveualted at compile time"),
 							)
+							if tv, ok := goFile.typeInfo.Types[t]; ok && tv.Value != nil {
+								lastViolation.addFix("Replace with the constant value",
+									goFile.newTextEdit(t.Pos(), t.End(), tv.Value.String()))
+							}
 						}
 					}
 				}
@@ -722,10 +877,12 @@ func (goFile *GoFile) detectStaticCondition() {
 				if obj != nil {
 					if _, ok := obj.(*types.Func); ok {
 						lastViolation = goFile.AddViolation(
-							t.Pos(),
+							t,
 							CONDITION_EVALUATED_STATICALLY,
 							fmt.Sprintf("Comparison of function %s is always %v", obj.Name(), t.Op == token.NEQ),
 						)
+						lastViolation.addFix("Replace with the constant value",
+							goFile.newTextEdit(t.Pos(), t.End(), fmt.Sprint(t.Op == token.NEQ)))
 					}
 				}
 			}
@@ -737,14 +894,14 @@ func (goFile *GoFile) detectStaticCondition() {
 
 // TODO: Implement.
 func (goFile *GoFile) detectRecursiveStringMethods() {
-	goFile.walk(func(node ast.Node) bool {
+	goFile.Walk(func(node ast.Node) bool {
 		return false
 	})
 }
 
-//TODO : Is it possible to actually do this without escape analysis?
+// TODO : Is it possible to actually do this without escape analysis?
 func (goFile *GoFile) detectBufferNotFlushed() {
-	goFile.walk(func(node ast.Node) bool {
+	goFile.Walk(func(node ast.Node) bool {
 		return true
 	})
 }
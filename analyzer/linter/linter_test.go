@@ -4,8 +4,13 @@
 package linter_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -34,10 +39,26 @@ func verifyViolations(expectedViolations []*linter.Violation, actualViolations [
 	return nil
 }
 
+// writeModule writes the sources by file path below dir, with a go.mod making dir the root of
+// a module, so the packages are also found in module mode.
+func writeModule(t *testing.T, dir string, sources map[string]string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/test\n\ngo 1.25\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for filePath, src := range sources {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(filePath)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, filePath), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 // Printing from fmt package is not thread safe and should be avoided in production and detected!
 // Testing rule: FMT_PRINTING
 func TestDetectionOfPrintingFromFmtPackage(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/fmtprinting")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/fmtprinting"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -59,7 +80,7 @@ func TestDetectionOfPrintingFromFmtPackage(t *testing.T) {
 // Testing rule: NEVER_ALLOCATED_MAP_WITH_NEW
 // Allocating maps with new returns a nil pointer, therefor one should use make.
 func TestDetectionOfAllocatingMapWithNew(t *testing.T) {
-	expectedViolations, _ := linter.DetectViolations("./testcode/newmap")
+	expectedViolations, _ := linter.DetectViolations(linter.NewConfig("./testcode/newmap"))
 	actualViolations := []actualViolation{
 		{SrcLine: 11, Type: linter.MAP_ALLOCATED_WITH_NEW},
 	}
@@ -76,7 +97,7 @@ func TestDetectionOfAllocatingMapWithNew(t *testing.T) {
 // Testing rule: RACE_CONDITION
 // Races will occur, since multiple Go-routines will share the same counter variable.
 func TestDetectionOfRacesInLoopClosures(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/threadlooping")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/threadlooping"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -96,7 +117,7 @@ func TestDetectionOfRacesInLoopClosures(t *testing.T) {
 // Testing rule: EMPTY_IF_BODY
 // Empty if-bodies are unnecessary and ineffective.
 func TestDetectionOfEmptyIfBody(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/emptyifbody")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/emptyifbody"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -116,7 +137,7 @@ func TestDetectionOfEmptyIfBody(t *testing.T) {
 // Testing rule: EMPTY_ELSE_BODY
 // Empty else-bodies are unnecessary and ineffective.
 func TestDetectionOfEmptyElseBody(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/emptyelsebody")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/emptyelsebody"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -133,10 +154,35 @@ func TestDetectionOfEmptyElseBody(t *testing.T) {
 	}
 }
 
+// Violations are located at the source code range of the offending node.
+func TestViolationPosition(t *testing.T) {
+	goPackages, err := linter.DetectViolations(linter.NewConfig("./testcode/emptyelsebody"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(goPackages) <= 0 {
+		t.Fatal("There is no functions containing violations.")
+	}
+	goFile := goPackages[0].Violations[0]
+	violation := goFile.Violations[0]
+
+	if violation.StartLine != 16 || violation.StartColumn != 9 || violation.EndLine != 18 || violation.EndColumn != 3 {
+		t.Errorf("Violation should be located at 16:9-18:3, and not %d:%d-%d:%d!", violation.StartLine,
+			violation.StartColumn, violation.EndLine, violation.EndColumn)
+	}
+	src, err := ioutil.ReadFile(goFile.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node := string(src[violation.StartOffset:violation.EndOffset]); node != "{\n\n\t}" {
+		t.Errorf("Violation should cover the empty else body, and not %q!", node)
+	}
+}
+
 // Testing rule: EMPTY_FOR_BODY
 // Empty for-bodies are unnecessary and ineffective.
 func TestDetectionOfEmptyForBody(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/emptyforbody")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/emptyforbody"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -156,7 +202,7 @@ func TestDetectionOfEmptyForBody(t *testing.T) {
 // Testing rule: RETURN_KILLS_CODE
 // One should never return unconditionally, except from the last statement in a func or method.
 func TestDetectionOfEarlyReturn(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/earlyreturn")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/earlyreturn"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -177,7 +223,7 @@ func TestDetectionOfEarlyReturn(t *testing.T) {
 // Jumping around in the code using GOTO (including BREAK, CONTINUE, GOTO, FALLTHROUGH)
 // is considered confusing and harmful.
 func TestDetectionOfGoTo(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/goto")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/goto"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -198,7 +244,7 @@ func TestDetectionOfGoTo(t *testing.T) {
 // Jumping around in the code using GOTO (including BREAK, CONTINUE, GOTO, FALLTHROUGH)
 // is considered confusing and harmful.
 func TestDetectionOfLabeledBranching(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/labeledbranch")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/labeledbranch"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -218,7 +264,7 @@ func TestDetectionOfLabeledBranching(t *testing.T) {
 // Testing rule: ERROR_IGNORED
 // Errors should never be ignored, might lead to program crashes.
 func TestDetectionOfIgnoredErrors(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/errorignored")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/errorignored"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -240,22 +286,49 @@ func TestDetectionOfIgnoredErrors(t *testing.T) {
 	}
 }
 
+// Packages are loaded with module awareness, so the error returned by a function
+// in another package of the module is known and its ignored error detected.
+func TestDetectionOfIgnoredErrorsInModule(t *testing.T) {
+	t.Setenv("GO111MODULE", "on")
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("GOTOOLCHAIN", "local")
+
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/module"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actualViolations := []actualViolation{
+		{SrcLine: 11, Type: linter.ERROR_IGNORED},
+	}
+
+	if len(expectedViolations) != 1 {
+		t.Fatalf("One package should contain violations, but %d packages do!", len(expectedViolations))
+	}
+	if expectedViolations[0].ImportPath != "github.com/chrisbbe/GoAnalysis/analyzer/linter/testcode/module" {
+		t.Errorf("Import path should be the path in go.mod, and not %s!", expectedViolations[0].ImportPath)
+	}
+	if err := verifyViolations(expectedViolations[0].Violations[0].Violations, actualViolations); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // Testing rule: CONDITION_EVALUATED_STATICALLY
 // Condition that can be evaluated statically are wasted and performance-reducing.
 func TestDetectionOfConditionEvaluatedStatically(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/staticconditions")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/staticconditions"))
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	actualViolations := []actualViolation{
-		{SrcLine: 14, Type: linter.CONDITION_EVALUATED_STATICALLY},
-		{SrcLine: 18, Type: linter.CONDITION_EVALUATED_STATICALLY},
-		{SrcLine: 22, Type: linter.CONDITION_EVALUATED_STATICALLY},
-		{SrcLine: 26, Type: linter.CONDITION_EVALUATED_STATICALLY},
-		//{SrcLine: 32, Type: linter.CONDITION_EVALUATED_STATICALLY}, TODO: Possible to statically trace a variable value?
-		{SrcLine: 38, Type: linter.CONDITION_EVALUATED_STATICALLY},
-		{SrcLine: 41, Type: linter.CONDITION_EVALUATED_STATICALLY},
+		{SrcLine: 15, Type: linter.CONDITION_EVALUATED_STATICALLY},
+		{SrcLine: 19, Type: linter.CONDITION_EVALUATED_STATICALLY},
+		{SrcLine: 23, Type: linter.CONDITION_EVALUATED_STATICALLY},
+		{SrcLine: 27, Type: linter.CONDITION_EVALUATED_STATICALLY},
+		//{SrcLine: 33, Type: linter.CONDITION_EVALUATED_STATICALLY}, TODO: Possible to statically trace a variable value?
+		{SrcLine: 39, Type: linter.CONDITION_EVALUATED_STATICALLY},
+		{SrcLine: 42, Type: linter.CONDITION_EVALUATED_STATICALLY},
 	}
 
 	if len(expectedViolations) <= 0 {
@@ -269,7 +342,7 @@ func TestDetectionOfConditionEvaluatedStatically(t *testing.T) {
 // GitHub Issue #4 list a scenario where the tool detects a false positive of rule ERROR_IGNORED.
 // This test verifies correction of the behaviour.
 func TestDetectionOfErrorIgnoredInReturn(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/errorinreturn")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/errorinreturn"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -285,13 +358,13 @@ func TestDetectionOfErrorIgnoredInReturn(t *testing.T) {
 }
 
 func TestDetectionOfHighCyclomatiComplexity(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/cyclomaticomplexity")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/cyclomaticomplexity"))
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	actualViolations := []actualViolation{
-		{SrcLine: 13, Type: linter.CYCLOMATIC_COMPLEXITY},
+		{SrcLine: 16, Type: linter.CYCLOMATIC_COMPLEXITY},
 	}
 
 	if len(expectedViolations) > 0 {
@@ -302,11 +375,57 @@ func TestDetectionOfHighCyclomatiComplexity(t *testing.T) {
 	}
 }
 
+// Function literals have a complexity of their own, reported at the literal.
+func TestDetectionOfHighCyclomatiComplexityInClosure(t *testing.T) {
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/closurecomplexity"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expectedViolations) != 1 || len(expectedViolations[0].Violations) != 1 {
+		t.Fatalf("Violations should be found in 1 file, but are %v!", expectedViolations)
+	}
+
+	violations := expectedViolations[0].Violations[0].Violations
+	if err := verifyViolations(violations, []actualViolation{{SrcLine: 12, Type: linter.CYCLOMATIC_COMPLEXITY}}); err != nil {
+		t.Fatal(err)
+	}
+	if violation := violations[0]; violation.StartColumn != 25 || violation.EndLine != 36 ||
+		!strings.Contains(violation.Description, "main.func1()") {
+		t.Errorf("Violation should cover the function literal main.func1, but is %+v!", violation)
+	}
+}
+
+// Packages analysed concurrently should give the same result as one at a time.
+func TestConcurrentAnalysisIsDeterministic(t *testing.T) {
+	var results []string
+	for _, jobs := range []int{1, 8} {
+		config := linter.NewConfig("./testcode")
+		config.Jobs = jobs
+		goPackages, err := linter.DetectViolations(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var goFiles []*linter.GoFile
+		for _, goPackage := range goPackages {
+			goFiles = append(goFiles, goPackage.Violations...)
+		}
+		result, err := json.Marshal(goFiles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, string(result))
+	}
+
+	if results[0] != results[1] {
+		t.Errorf("Violations found by 8 workers should equal those found by 1 worker!\n%s\n%s", results[1], results[0])
+	}
+}
+
 //TODO: Implement!
 /*
 // Testing rule: STRING_METHOD_DEFINES_ITSELF
 func TestDetectionOfStringMethodDefiningItself(t *testing.T) {
-	expectedViolations, err := linter.DetectViolations("./testcode/stringmethod")
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/stringmethod"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,161 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DEFAULT_PATTERN selects all packages in and below the analysed directory.
+const DEFAULT_PATTERN = "./..."
+
+// loadMode loads the syntax and type information of the matched packages, while
+// dependencies are type checked from export data or source by the go command.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes |
+	packages.NeedTypesInfo
+
+// loadPackages loads, parses and type checks the packages matching config.Patterns in
+// config.Dir for the build configuration, like the go command does. Packages are found
+// through go.mod in module mode, or GOPATH otherwise. Files excluded by the configuration
+// are type checked, but left out of the returned packages, and packages without files
+// are skipped. With tests, packages are replaced by their test variant. Packages having
+// results cached in config.CacheDir are not parsed, nor analysed again.
+func loadPackages(config *Config, buildConfig BuildConfig) ([]*GoPackage, error) {
+	absDir, err := filepath.Abs(config.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if realDir, err := filepath.EvalSymlinks(absDir); err == nil {
+		absDir = realDir // The go command reports file paths without symbolic links.
+	}
+
+	if config.CacheDir != "" && len(config.Overlay) == 0 {
+		return loadCachedPackages(config, buildConfig, absDir)
+	}
+	return loadSyntax(config, buildConfig, absDir, config.patterns())
+}
+
+// loadSyntax loads, parses and type checks the packages matching patterns for the build
+// configuration, absDir is config.Dir in absolute form.
+func loadSyntax(config *Config, buildConfig BuildConfig, absDir string, patterns []string) ([]*GoPackage, error) {
+	fileSet := token.NewFileSet()
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:       loadMode,
+		Dir:        config.Dir,
+		Env:        buildConfig.env(),
+		BuildFlags: buildConfig.buildFlags(),
+		Tests:      buildConfig.Tests,
+		Fset:       fileSet,
+		Overlay:    config.Overlay,
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	var goPackages []*GoPackage
+	for _, pkg := range selectPackages(pkgs) {
+		if len(pkg.GoFiles) == 0 && len(pkg.Errors) > 0 {
+			goPackages = append(goPackages, config.failedPackage(absDir, pkg))
+			continue
+		}
+
+		goFiles := make(map[string]bool)
+		for _, goFile := range pkg.GoFiles {
+			goFiles[goFile] = true
+		}
+
+		files := make(map[string]*ast.File)
+		packageDir := ""
+		for _, file := range pkg.Syntax {
+			filePath := fileSet.Position(file.Package).Filename
+			if !goFiles[filePath] {
+				continue // Generated by cgo.
+			}
+			if displayPath, ok := config.includedFile(absDir, filePath); ok {
+				files[displayPath] = file
+				packageDir = filepath.Dir(displayPath)
+			}
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		goPackage := &GoPackage{
+			Path:        packageDir,
+			ImportPath:  pkg.PkgPath,
+			Pack:        &ast.Package{Name: pkg.Name, Files: files},
+			fileSet:     fileSet,
+			typeInfo:    pkg.TypesInfo,
+			typeChecker: &typeChecker{fileSet: fileSet, pkg: pkg.Types, files: pkg.Syntax},
+			config:      config,
+			id:          pkg.ID,
+		}
+		goPackage.Diagnostics = config.newDiagnostics(absDir, pkg)
+		goPackages = append(goPackages, goPackage)
+	}
+	return goPackages, nil
+}
+
+// selectPackages returns the packages to analyse among pkgs. Packages having a test variant,
+// "p [p.test]", are replaced by it, as it includes all files of the package itself, and the
+// generated test main packages are left out.
+func selectPackages(pkgs []*packages.Package) []*packages.Package {
+	testedPackages := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.ID != pkg.PkgPath && strings.HasSuffix(pkg.ID, ".test]") {
+			testedPackages[pkg.PkgPath] = true
+		}
+	}
+
+	var selected []*packages.Package
+	for _, pkg := range pkgs {
+		if pkg.ID == pkg.PkgPath && testedPackages[pkg.PkgPath] || strings.HasSuffix(pkg.ID, ".test") {
+			continue
+		}
+		selected = append(selected, pkg)
+	}
+	return selected
+}
+
+// includedFile returns the path of the file at the absolute filePath to report, and whether
+// the file is included by the configuration.
+func (config *Config) includedFile(absDir string, filePath string) (string, bool) {
+	filePath = displayPath(config.Dir, absDir, filePath)
+	return filePath, config.FileIncluded(filePath) && !config.parentDirExcluded(filePath)
+}
+
+// patterns returns the package patterns to analyse, DEFAULT_PATTERN if none are configured.
+func (config *Config) patterns() []string {
+	if len(config.Patterns) == 0 {
+		return []string{DEFAULT_PATTERN}
+	}
+	return config.Patterns
+}
+
+// parentDirExcluded returns true if any directory between the configuration root and
+// the file at filePath is excluded.
+func (config *Config) parentDirExcluded(filePath string) bool {
+	for dir := filepath.Dir(filePath); ; dir = filepath.Dir(dir) {
+		if config.DirExcluded(dir) {
+			return true
+		}
+		if parent := filepath.Dir(dir); parent == dir || strings.HasPrefix(parent, "..") {
+			return false
+		}
+	}
+}
+
+// displayPath returns the path of the absolute filePath below dir, which is absDir in
+// absolute form, so reported paths are relative if the analysed directory is relative.
+func displayPath(dir string, absDir string, filePath string) string {
+	if relPath, err := filepath.Rel(absDir, filePath); err == nil && !strings.HasPrefix(relPath, "..") {
+		return filepath.Join(dir, relPath)
+	}
+	return filePath
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Rule is the unique key identifying a rule, e.g. "FMT_PRINTING".
+// Rules defined outside this package are declared the same way:
+//
+//	const MY_RULE linter.Rule = "MY_RULE"
+type Rule string
+
+func (rule Rule) String() string {
+	return string(rule)
+}
+
+// Severity is the importance of a rule violation, using the same levels as SonarQube.
+type Severity int
+
+// Severities, from least to most important.
+const (
+	INFO Severity = iota
+	MINOR
+	MAJOR
+	CRITICAL
+	BLOCKER
+)
+
+var severityStrings = [...]string{
+	INFO:     "INFO",
+	MINOR:    "MINOR",
+	MAJOR:    "MAJOR",
+	CRITICAL: "CRITICAL",
+	BLOCKER:  "BLOCKER",
+}
+
+func (severity Severity) String() string {
+	if severity < INFO || severity > BLOCKER {
+		return fmt.Sprintf("Severity(%d)", int(severity))
+	}
+	return severityStrings[severity]
+}
+
+// Marshal Severity string value instead of int value.
+func (severity Severity) MarshalText() ([]byte, error) {
+	return []byte(severity.String()), nil
+}
+
+// UnmarshalText parses the severity from its string value.
+func (severity *Severity) UnmarshalText(text []byte) error {
+	s, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*severity = s
+	return nil
+}
+
+// ParseSeverity returns the Severity named by s, case insensitive.
+func ParseSeverity(s string) (Severity, error) {
+	for severity, name := range severityStrings {
+		if strings.EqualFold(name, s) {
+			return Severity(severity), nil
+		}
+	}
+	return INFO, fmt.Errorf("unknown severity %q", s)
+}
+
+// RuleInfo holds the metadata describing a rule.
+type RuleInfo struct {
+	Rule        Rule     // Unique rule key.
+	Name        string   // Short human readable name.
+	Severity    Severity // Default severity of violations.
+	Tags        []string // Categories, e.g. "bug" or "bad-practice".
+	Description string   // Longer explanation of why the rule exists.
+	IntParams   []string // Integer parameters configurable in the params of the rule, e.g. "limit".
+}
+
+// Checker is implemented by every rule that can be run against a Go source file.
+// Check reports violations through goFile.AddViolation.
+type Checker interface {
+	Info() *RuleInfo
+	Check(goFile *GoFile)
+}
+
+type funcChecker struct {
+	info  RuleInfo
+	check func(goFile *GoFile)
+}
+
+func (checker *funcChecker) Info() *RuleInfo {
+	return &checker.info
+}
+
+func (checker *funcChecker) Check(goFile *GoFile) {
+	checker.check(goFile)
+}
+
+// NewChecker returns a Checker described by info, running check on every file.
+func NewChecker(info RuleInfo, check func(goFile *GoFile)) Checker {
+	return &funcChecker{info: info, check: check}
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []Checker
+	ruleInfos  = map[Rule]*RuleInfo{}
+)
+
+// Register makes a checker available to DetectViolations. It is meant to be called
+// from init functions, and panics if the checker is nil or its rule is already registered.
+func Register(checker Checker) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if checker == nil {
+		panic("linter: Register checker is nil")
+	}
+	info := checker.Info()
+	if info == nil || len(info.Rule) == 0 {
+		panic("linter: Register checker without rule key")
+	}
+	if _, dup := ruleInfos[info.Rule]; dup {
+		panic("linter: Register called twice for rule " + info.Rule.String())
+	}
+	registry = append(registry, checker)
+	ruleInfos[info.Rule] = info
+}
+
+// Checkers returns all registered checkers, in registration order.
+func Checkers() []Checker {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Checker(nil), registry...)
+}
+
+// LookupRule returns the metadata of a registered rule, or nil if no such rule exists.
+func LookupRule(rule Rule) *RuleInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return ruleInfos[rule]
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"go/ast"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+func TestBuiltInRulesAreRegistered(t *testing.T) {
+	rules := []linter.Rule{
+		linter.CYCLOMATIC_COMPLEXITY,
+		linter.FMT_PRINTING,
+		linter.MAP_ALLOCATED_WITH_NEW,
+		linter.EMPTY_IF_BODY,
+		linter.EMPTY_ELSE_BODY,
+		linter.EMPTY_FOR_BODY,
+		linter.GOTO_USED,
+		linter.RACE_CONDITION,
+		linter.RETURN_KILLS_CODE,
+		linter.ERROR_IGNORED,
+		linter.STRING_CALLS_ITSELF,
+		linter.CONDITION_EVALUATED_STATICALLY,
+	}
+
+	for _, rule := range rules {
+		info := linter.LookupRule(rule)
+		if info == nil {
+			t.Fatalf("Rule %s should be registered!", rule)
+		}
+		if len(info.Name) == 0 || len(info.Description) == 0 {
+			t.Errorf("Rule %s should have both name and description!", rule)
+		}
+	}
+}
+
+func TestRegisterDuplicateRulePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Registering FMT_PRINTING twice should panic!")
+		}
+	}()
+	linter.Register(linter.NewChecker(linter.RuleInfo{Rule: linter.FMT_PRINTING}, func(goFile *linter.GoFile) {}))
+}
+
+const INIT_FUNCTION_USED linter.Rule = "INIT_FUNCTION_USED"
+
+// Rules registered from outside the linter package, like house rules, are run by DetectViolations.
+func init() {
+	linter.Register(linter.NewChecker(linter.RuleInfo{
+		Rule:        INIT_FUNCTION_USED,
+		Name:        "Init function used",
+		Severity:    linter.INFO,
+		Description: "Test rule flagging every init function.",
+	}, func(goFile *linter.GoFile) {
+		goFile.Walk(func(node ast.Node) bool {
+			if funcDecl, ok := node.(*ast.FuncDecl); ok && funcDecl.Recv == nil && funcDecl.Name.Name == "init" {
+				goFile.AddViolation(funcDecl, INIT_FUNCTION_USED, "Init function used")
+			}
+			return true
+		})
+	}))
+}
+
+func TestDetectionWithRegisteredChecker(t *testing.T) {
+	goPackages, err := linter.DetectViolations(linter.NewConfig("./testcode/houserule"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actualViolations := []actualViolation{
+		{SrcLine: 8, Type: INIT_FUNCTION_USED},
+	}
+
+	if len(goPackages) <= 0 {
+		t.Fatal("There is no functions containing violations.")
+	}
+	if err := verifyViolations(goPackages[0].Violations[0].Violations, actualViolations); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteSonarRules(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := linter.WriteSonarRules(&buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	var rules struct {
+		Keys []string `xml:"rule>key"`
+	}
+	if err := xml.Unmarshal(buffer.Bytes(), &rules); err != nil {
+		t.Fatal(err)
+	}
+	if len(rules.Keys) != len(linter.Checkers()) {
+		t.Fatalf("Number of rules should be %d, but are %d!", len(linter.Checkers()), len(rules.Keys))
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter
+
+// Rules.
+const (
+	RACE_CONDITION                 Rule = "RACE_CONDITION"
+	FMT_PRINTING                   Rule = "FMT_PRINTING"
+	STRING_CALLS_ITSELF            Rule = "STRING_CALLS_ITSELF"
+	MAP_ALLOCATED_WITH_NEW         Rule = "MAP_ALLOCATED_WITH_NEW"
+	ERROR_IGNORED                  Rule = "ERROR_IGNORED"
+	EMPTY_IF_BODY                  Rule = "EMPTY_IF_BODY"
+	EMPTY_ELSE_BODY                Rule = "EMPTY_ELSE_BODY"
+	EMPTY_FOR_BODY                 Rule = "EMPTY_FOR_BODY"
+	GOTO_USED                      Rule = "GOTO_USED"
+	CONDITION_EVALUATED_STATICALLY Rule = "CONDITION_EVALUATED_STATICALLY"
+	BUFFER_NOT_FLUSHED             Rule = "NO_BUFFERED_FLUSHING"
+	RETURN_KILLS_CODE              Rule = "RETURN_KILLS_CODE"
+	CYCLOMATIC_COMPLEXITY          Rule = "CYCLOMATIC_COMPLEXITY"
+	INVALID_SUPPRESSION            Rule = "INVALID_SUPPRESSION"
+	UNUSED_SUPPRESSION             Rule = "UNUSED_SUPPRESSION"
+)
+
+// Register the built-in rules, the order decides the order they are run in.
+// BUFFER_NOT_FLUSHED is not registered until detectBufferNotFlushed is implemented.
+func init() {
+	Register(NewChecker(RuleInfo{
+		Rule:     CYCLOMATIC_COMPLEXITY,
+		Name:     "Methods and functions should not be too complex",
+		Severity: MAJOR,
+		Tags:     []string{"brain-overload"},
+		Description: "The cyclomatic complexity of methods should not exceed a defined threshold. Complex code can " +
+			"perform poorly and will in any case be difficult to understand and therefore to maintain.",
+		IntParams: []string{"limit"},
+	}, (*GoFile).detectHighCyclomaticComplexity))
+
+	Register(NewChecker(RuleInfo{
+		Rule:     FMT_PRINTING,
+		Name:     "Printing from the fmt package",
+		Severity: INFO,
+		Tags:     []string{"bad-practice"},
+		Description: "Printing directly to stdout is not synchronized among Goroutines and might clog the " +
+			"standard output.",
+	}, (*GoFile).detectFmtPrinting))
+
+	Register(NewChecker(RuleInfo{
+		Rule:     MAP_ALLOCATED_WITH_NEW,
+		Name:     "Map allocated with new()",
+		Severity: CRITICAL,
+		Tags:     []string{"bug"},
+		Description: "Maps are allocated, not initialized with new(). Use make() to allocate and initialize " +
+			"the map.",
+	}, (*GoFile).detectMapsAllocatedWithNew))
+
+	Register(NewChecker(RuleInfo{
+		Rule:        EMPTY_IF_BODY,
+		Name:        "If statement has empty body",
+		Severity:    MINOR,
+		Tags:        []string{"bad-practice"},
+		Description: "An empty If statement body does nothing.",
+	}, (*GoFile).detectEmptyIfBody))
+
+	Register(NewChecker(RuleInfo{
+		Rule:        EMPTY_ELSE_BODY,
+		Name:        "Else statement has empty body",
+		Severity:    MINOR,
+		Tags:        []string{"bad-practice"},
+		Description: "An empty Else statement body does nothing.",
+	}, (*GoFile).detectEmptyElseBody))
+
+	Register(NewChecker(RuleInfo{
+		Rule:        EMPTY_FOR_BODY,
+		Name:        "For statement has empty body",
+		Severity:    MINOR,
+		Tags:        []string{"bad-practice"},
+		Description: "An empty For statement body does nothing.",
+	}, (*GoFile).detectEmptyForBody))
+
+	Register(NewChecker(RuleInfo{
+		Rule:        GOTO_USED,
+		Name:        "Goto or labeled branch statement used",
+		Severity:    MAJOR,
+		Tags:        []string{"bad-practice"},
+		Description: "Usage of GOTO statements might lead to spaghetti code.",
+	}, (*GoFile).detectGoToStatements))
+
+	Register(NewChecker(RuleInfo{
+		Rule:        RACE_CONDITION,
+		Name:        "Goroutines on loop iterator variables creates races",
+		Severity:    BLOCKER,
+		Tags:        []string{"bug"},
+		Description: "Goroutines on loop iterator variables creates races.",
+	}, (*GoFile).detectRaceInGoRoutine))
+
+	Register(NewChecker(RuleInfo{
+		Rule:     RETURN_KILLS_CODE,
+		Name:     "Return statement kills code",
+		Severity: MINOR,
+		Tags:     []string{"bad-practice"},
+		Description: "Code following an unconditional return is dead, there is no possible execution path " +
+			"to it.",
+	}, (*GoFile).detectReturnKillingCode))
+
+	Register(NewChecker(RuleInfo{
+		Rule:        ERROR_IGNORED,
+		Name:        "Error ignored",
+		Severity:    MINOR,
+		Tags:        []string{"bad-practice"},
+		Description: "Never ignore errors, ignoring them can lead to program crashes!",
+	}, (*GoFile).detectIgnoredErrors))
+
+	Register(NewChecker(RuleInfo{
+		Rule:     STRING_CALLS_ITSELF,
+		Name:     "String() method calls itself",
+		Severity: BLOCKER,
+		Tags:     []string{"bug"},
+		Description: "String() method calls itself by using format functions with %v or %s directly on the " +
+			"method type.",
+	}, (*GoFile).detectRecursiveStringMethods))
+
+	Register(NewChecker(RuleInfo{
+		Rule:        CONDITION_EVALUATED_STATICALLY,
+		Name:        "Condition can be evaluated statically",
+		Severity:    MINOR,
+		Tags:        []string{"bad-practice"},
+		Description: "There is no need to evaluate condition that clearly are false or true.",
+	}, (*GoFile).detectStaticCondition))
+
+	Register(NewChecker(RuleInfo{
+		Rule:     INVALID_SUPPRESSION,
+		Name:     "Suppression directive is invalid",
+		Severity: MINOR,
+		Tags:     []string{"bad-practice"},
+		Description: "A @SuppressRule directive must name registered rules, give a reason justifying it and " +
+			"trail code or precede a declaration or statement. Invalid directives suppress nothing.",
+	}, reportedBySuppressions))
+
+	Register(NewChecker(RuleInfo{
+		Rule:        UNUSED_SUPPRESSION,
+		Name:        "Suppression directive is unused",
+		Severity:    INFO,
+		Tags:        []string{"unused"},
+		Description: "A @SuppressRule directive suppresses no violations of a rule, it is stale and can be removed.",
+	}, reportedBySuppressions))
+}
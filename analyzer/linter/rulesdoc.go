@@ -0,0 +1,77 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sonarRule is the XML representation of a rule in the sonar-go-plugin go-rules.xml file.
+type sonarRule struct {
+	Key         string   `xml:"key"`
+	Name        string   `xml:"name"`
+	InternalKey string   `xml:"internalKey"`
+	Description string   `xml:"description"`
+	Severity    string   `xml:"severity"`
+	Cardinality string   `xml:"cardinality"`
+	Status      string   `xml:"status"`
+	Tags        []string `xml:"tag"`
+}
+
+type sonarRules struct {
+	XMLName xml.Name     `xml:"go-rules"`
+	Rules   []*sonarRule `xml:"rule"`
+}
+
+// WriteSonarRules writes the rule definitions of all registered rules to w,
+// in the format of the sonar-go-plugin go-rules.xml file.
+func WriteSonarRules(w io.Writer) error {
+	rules := sonarRules{}
+	for _, checker := range Checkers() {
+		info := checker.Info()
+		rules.Rules = append(rules.Rules, &sonarRule{
+			Key:         info.Rule.String(),
+			Name:        info.Name,
+			InternalKey: info.Rule.String(),
+			Description: info.Description,
+			Severity:    info.Severity.String(),
+			Cardinality: "SINGLE",
+			Status:      "READY",
+			Tags:        info.Tags,
+		})
+	}
+
+	content, err := xml.MarshalIndent(rules, "", "    ")
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(append(content, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// WriteMarkdownRules writes a Markdown table of all registered rules to w,
+// as listed on the wiki page.
+func WriteMarkdownRules(w io.Writer) error {
+	if _, err := io.WriteString(w, "| Rule | Name | Severity | Tags | Description |\n|---|---|---|---|---|\n"); err != nil {
+		return err
+	}
+	for _, checker := range Checkers() {
+		info := checker.Info()
+		if _, err := fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n", info.Rule, escapeMarkdown(info.Name),
+			info.Severity, strings.Join(info.Tags, ", "), escapeMarkdown(info.Description)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeMarkdown escapes characters breaking a Markdown table cell.
+func escapeMarkdown(text string) string {
+	return strings.Replace(text, "|", "\\|", -1)
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"math"
+	"regexp"
+	"strings"
+)
+
+var (
+	// suppressDirective matches @SuppressRule("RULE_A", "RULE_B", reason="Justification"), capturing the arguments.
+	suppressDirective = regexp.MustCompile(`@SuppressRule\(((?:[^)"]|"[^"]*")*)\)`)
+	// suppressArgument matches the next argument of a directive, capturing its name and quoted value.
+	suppressArgument = regexp.MustCompile(`^\s*(?:(\w+)\s*=\s*)?"([^"]*)"\s*(?:,|$)`)
+)
+
+// suppression is a @SuppressRule directive, suppressing violations of its rules starting on the lines
+// from startLine to endLine. A directive trailing code applies to that line, a directive in the comment
+// before a declaration or statement applies to all of it and a directive before the package clause
+// applies to the whole file.
+type suppression struct {
+	comment   *ast.Comment
+	rules     []Rule
+	reason    string
+	startLine int
+	endLine   int
+	used      map[Rule]bool // Rules violations were suppressed of.
+}
+
+// reportedBySuppressions is the check of the INVALID_SUPPRESSION and UNUSED_SUPPRESSION rules, which
+// are reported by applySuppressions once all other checkers have run.
+func reportedBySuppressions(goFile *GoFile) {}
+
+// applySuppressions removes the violations suppressed by @SuppressRule directives in the file, reporting
+// invalid directives as INVALID_SUPPRESSION and directives not suppressing any violation of a rule
+// as UNUSED_SUPPRESSION.
+func (goFile *GoFile) applySuppressions() {
+	if goFile.goFileNode == nil {
+		return
+	}
+	suppressions := goFile.suppressions()
+
+	var violations []*Violation
+	for _, violation := range goFile.Violations {
+		suppressed := false
+		for _, suppression := range suppressions {
+			suppressed = suppression.suppresses(violation) || suppressed
+		}
+		if !suppressed {
+			violations = append(violations, violation)
+		}
+	}
+	goFile.Violations = violations
+
+	if !goFile.checksRule(UNUSED_SUPPRESSION) {
+		return
+	}
+	for _, suppression := range suppressions {
+		var unused []string
+		for _, rule := range suppression.rules {
+			if !suppression.used[rule] && goFile.checksRule(rule) {
+				unused = append(unused, rule.String())
+			}
+		}
+		if len(unused) > 0 {
+			goFile.AddViolation(suppression.comment, UNUSED_SUPPRESSION,
+				fmt.Sprintf("No violations of %s are suppressed, remove the suppression", strings.Join(unused, ", ")))
+		}
+	}
+}
+
+// suppresses returns true if the suppression applies to the violation, marking the rule used.
+func (suppression *suppression) suppresses(violation *Violation) bool {
+	if violation.StartLine < suppression.startLine || violation.StartLine > suppression.endLine {
+		return false
+	}
+	for _, rule := range suppression.rules {
+		if rule == violation.Type {
+			suppression.used[rule] = true
+			return true
+		}
+	}
+	return false
+}
+
+// suppressions returns the valid @SuppressRule directives in the file, reporting the invalid ones.
+func (goFile *GoFile) suppressions() []*suppression {
+	lineOf := func(pos token.Pos) int { return goFile.fileSet.Position(pos).Line }
+
+	// Earliest end of code on every line, telling whether a comment trails code.
+	codeEnds := make(map[int]token.Pos)
+	goFile.Walk(func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		if end, ok := codeEnds[lineOf(node.End())]; !ok || node.End() < end {
+			codeEnds[lineOf(node.End())] = node.End()
+		}
+		return true
+	})
+
+	var suppressions []*suppression
+	for _, commentGroup := range goFile.goFileNode.Comments {
+		for _, comment := range commentGroup.List {
+			for _, match := range suppressDirective.FindAllStringSubmatch(comment.Text, -1) {
+				suppression, err := parseSuppression(match[1])
+				if err == nil {
+					suppression.comment = comment
+					err = goFile.scopeSuppression(suppression, commentGroup, codeEnds, lineOf)
+				}
+				if err != nil {
+					if goFile.checksRule(INVALID_SUPPRESSION) {
+						goFile.AddViolation(comment, INVALID_SUPPRESSION, fmt.Sprintf("Invalid suppression, %s", err))
+					}
+					continue
+				}
+				suppressions = append(suppressions, suppression)
+			}
+		}
+	}
+	return suppressions
+}
+
+// parseSuppression parses the arguments of a @SuppressRule directive, being quoted rule names
+// and a quoted reason, like "RULE_A", "RULE_B", reason="Justification".
+func parseSuppression(arguments string) (*suppression, error) {
+	suppression := &suppression{used: make(map[Rule]bool)}
+	for rest := arguments; strings.TrimSpace(rest) != ""; {
+		match := suppressArgument.FindStringSubmatch(rest)
+		if match == nil {
+			return nil, fmt.Errorf("arguments must be quoted rule names and reason=\"...\", not %s", arguments)
+		}
+		rest = rest[len(match[0]):]
+
+		switch match[1] {
+		case "":
+			if LookupRule(Rule(match[2])) == nil {
+				return nil, fmt.Errorf("unknown rule %q", match[2])
+			}
+			suppression.rules = append(suppression.rules, Rule(match[2]))
+		case "reason":
+			suppression.reason = strings.TrimSpace(match[2])
+		default:
+			return nil, fmt.Errorf("unknown argument %s", match[1])
+		}
+	}
+
+	if len(suppression.rules) == 0 {
+		return nil, fmt.Errorf("no rules are given")
+	}
+	if suppression.reason == "" {
+		return nil, fmt.Errorf("a reason=\"...\" justifying it is required")
+	}
+	return suppression, nil
+}
+
+// scopeSuppression sets the lines the suppression in commentGroup applies to.
+func (goFile *GoFile) scopeSuppression(suppression *suppression, commentGroup *ast.CommentGroup,
+	codeEnds map[int]token.Pos, lineOf func(token.Pos) int) error {
+	line := lineOf(suppression.comment.Pos())
+	if end, ok := codeEnds[line]; ok && end <= suppression.comment.Pos() {
+		suppression.startLine, suppression.endLine = line, line
+		return nil
+	}
+	if suppression.comment.Pos() < goFile.goFileNode.Package {
+		suppression.startLine, suppression.endLine = 1, math.MaxInt32
+		return nil
+	}
+
+	// The outermost node starting on the line after the comment is visited first.
+	var next ast.Node
+	nextLine := lineOf(commentGroup.End()) + 1
+	goFile.Walk(func(node ast.Node) bool {
+		if node == nil || next != nil || lineOf(node.End()) < nextLine {
+			return false
+		}
+		if lineOf(node.Pos()) == nextLine {
+			next = node
+			return false
+		}
+		return lineOf(node.Pos()) < nextLine
+	})
+	if next == nil {
+		return fmt.Errorf("it must trail code or precede a declaration or statement")
+	}
+	suppression.startLine, suppression.endLine = nextLine, lineOf(next.End())
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package linter_test
+
+import (
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+// Violations are suppressed on the line, in the declaration or statement and in the file
+// the directive applies to, invalid and unused directives are reported.
+func TestSuppressionDirectives(t *testing.T) {
+	expectedViolations, err := linter.DetectViolations(linter.NewConfig("./testcode/suppression"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actualViolations := []actualViolation{
+		{SrcLine: 10, Type: linter.EMPTY_IF_BODY},
+		{SrcLine: 19, Type: linter.EMPTY_IF_BODY},
+		{SrcLine: 21, Type: linter.EMPTY_IF_BODY},
+		{SrcLine: 19, Type: linter.INVALID_SUPPRESSION},
+		{SrcLine: 21, Type: linter.INVALID_SUPPRESSION},
+		{SrcLine: 23, Type: linter.UNUSED_SUPPRESSION},
+	}
+
+	if len(expectedViolations) != 1 || len(expectedViolations[0].Violations) != 1 {
+		t.Fatal("Violations should only be found in main.go, file.go suppresses its violations.")
+	}
+	if err := verifyViolations(expectedViolations[0].Violations[0].Violations, actualViolations); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Suppressions of disabled rules are not reported as unused.
+func TestSuppressionOfDisabledRule(t *testing.T) {
+	config := linter.NewConfig("./testcode/suppression")
+	disabled := false
+	config.Rules = map[linter.Rule]*linter.RuleConfig{
+		linter.EMPTY_IF_BODY:       {Enabled: &disabled},
+		linter.EMPTY_FOR_BODY:      {Enabled: &disabled},
+		linter.INVALID_SUPPRESSION: {Enabled: &disabled},
+	}
+	expectedViolations, err := linter.DetectViolations(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expectedViolations) != 0 {
+		t.Fatalf("No violations should be found, but %v are!", expectedViolations[0].Violations[0].Violations)
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+
+//go:build custom
+
+package buildtags
+
+func customOnly(x int) {
+	if x > 0 {
+	}
+}
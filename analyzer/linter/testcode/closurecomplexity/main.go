@@ -0,0 +1,38 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package main
+
+import "fmt"
+
+// Unit-test, ignore other errors then what we are testing.
+// @SuppressRule("FMT_PRINTING", reason="Only CYCLOMATIC_COMPLEXITY is tested.")
+// @SuppressRule("ERROR_IGNORED", reason="Only CYCLOMATIC_COMPLEXITY is tested.")
+func main() {
+	monthNumberToString := func(month int) string {
+		switch month {
+		case 1:
+			return "January"
+		case 2:
+			return "February"
+		case 3:
+			return "March"
+		case 4:
+			return "April"
+		case 5:
+			return "May"
+		case 6:
+			return "June"
+		case 7:
+			return "July"
+		case 8:
+			return "August"
+		case 9:
+			return "September"
+		case 10:
+			return "October"
+		}
+		return "Unknown"
+	}
+	fmt.Println(monthNumberToString(10))
+}
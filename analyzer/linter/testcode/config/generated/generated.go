@@ -0,0 +1,10 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package generated
+
+// Generated code is excluded in the project configuration.
+func Generated() {
+	for {
+	}
+}
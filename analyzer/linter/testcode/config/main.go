@@ -0,0 +1,16 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+func main() {
+	if rand.Intn(10) == 5 {
+	} else {
+		fmt.Println("Printing is allowed in this project")
+	}
+}
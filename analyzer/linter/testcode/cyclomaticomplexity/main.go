@@ -5,6 +5,9 @@ package main
 
 import "fmt"
 
+// Unit-test, ignore other errors then what we are testing.
+// @SuppressRule("FMT_PRINTING", reason="Only CYCLOMATIC_COMPLEXITY is tested.")
+// @SuppressRule("ERROR_IGNORED", reason="Only CYCLOMATIC_COMPLEXITY is tested.")
 func main() {
 	month := 10
 	fmt.Printf("Month %d is %s\n", month, monthNumberToString(month))
@@ -6,7 +6,7 @@ package main
 import "fmt"
 
 // Unit-test, ignore other errors then what we are testing.
-// @SuppressRule("ERROR_IGNORED")
+// @SuppressRule("ERROR_IGNORED", reason="Only FMT_PRINTING is tested.")
 func main() {
 	fmt.Print("Printing with fmt.Print()")
 	fmt.Printf("Printing with fmt.Printf()")
@@ -0,0 +1,3 @@
+module github.com/chrisbbe/GoAnalysis/analyzer/linter/testcode
+
+go 1.25.0
@@ -0,0 +1,14 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package main
+
+import "log"
+
+func init() {
+	log.SetFlags(log.Lshortfile)
+}
+
+func main() {
+	log.Println("House rules are compiled in from other packages")
+}
@@ -0,0 +1,3 @@
+module github.com/chrisbbe/GoAnalysis/analyzer/linter/testcode/module
+
+go 1.21
@@ -0,0 +1,12 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package main
+
+import (
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter/testcode/module/dep"
+)
+
+func main() {
+	dep.Do()
+}
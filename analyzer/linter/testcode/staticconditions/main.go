@@ -10,6 +10,7 @@ import (
 	"os"
 )
 
+// @SuppressRule("CYCLOMATIC_COMPLEXITY", reason="Only CONDITION_EVALUATED_STATICALLY is tested.")
 func main() {
 	if true {
 		log.Println("Always true")
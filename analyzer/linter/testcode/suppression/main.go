@@ -0,0 +1,33 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package main
+
+func main() {
+	x := len("abc")
+	if x > 0 { // @SuppressRule("EMPTY_IF_BODY", reason="Line scope.")
+	}
+	if x > 1 {
+	}
+	// @SuppressRule("EMPTY_IF_BODY", "EMPTY_FOR_BODY", reason="Block scope.")
+	for i := 0; i < x; i++ {
+		if i > 1 {
+		}
+		for j := 0; j < i; j++ {
+		}
+	}
+	if x > 2 { // @SuppressRule("EMPTY_IF_BODY")
+	}
+	if x > 3 { // @SuppressRule("NO_SUCH_RULE", reason="Unknown rule.")
+	}
+	x++ // @SuppressRule("EMPTY_FOR_BODY", reason="Nothing to suppress.")
+	jump()
+	loop()
+}
+
+// @SuppressRule("GOTO_USED", reason="Function scope.")
+func jump() {
+	goto end
+end:
+	return
+}
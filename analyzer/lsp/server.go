@@ -0,0 +1,467 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+
+// Package lsp implements a Language Server Protocol server publishing the violations found
+// by the linter in the documents open in an editor, as they are typed.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/globalvars"
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+// DEFAULT_DELAY is the time waited for more changes before a changed document is analysed.
+const DEFAULT_DELAY = 300 * time.Millisecond
+
+// Server is a language server analysing the packages of the open Go source files with the
+// registered rules, using the unsaved content of the documents. Violations are published as
+// diagnostics, and their suggested fixes are offered as code actions.
+type Server struct {
+	Delay time.Duration // Time waited for more changes before a changed document is analysed.
+
+	out     io.Writer
+	writeMu sync.Mutex // Serialises writes to out.
+
+	mu          sync.Mutex
+	documents   map[string]*document   // Open documents by URI.
+	timers      map[string]*time.Timer // Scheduled analyses by package directory.
+	initialized bool
+	shutdown    bool
+}
+
+// document is a Go source file open in the editor.
+type document struct {
+	uri     string
+	path    string // Absolute path of the file.
+	version int
+	content []byte
+	result  *analysis // Latest analysis of the document, nil if there is none.
+}
+
+// analysis is the result of analysing a version of a document.
+type analysis struct {
+	document *document
+	version  int
+	content  []byte
+	goFiles  []*linter.GoFile // Analysed files at the path of the document, under every build.
+}
+
+// NewServer returns a server waiting DEFAULT_DELAY before analysing changed documents.
+func NewServer() *Server {
+	return &Server{
+		Delay:     DEFAULT_DELAY,
+		documents: make(map[string]*document),
+		timers:    make(map[string]*time.Timer),
+	}
+}
+
+// Serve reads requests and notifications from in, writing responses and notifications to out,
+// until the client sends exit. An error is returned if the client did not shut the server down
+// first, or in is closed before.
+func (server *Server) Serve(in io.Reader, out io.Writer) error {
+	server.out = out
+	defer server.stopAnalyses()
+
+	reader := bufio.NewReader(in)
+	for {
+		content, err := readMessage(reader)
+		if err == io.EOF {
+			return errors.New("input closed before exit")
+		} else if err != nil {
+			return err
+		}
+
+		req := &request{}
+		if err := json.Unmarshal(content, req); err != nil {
+			server.respond(json.RawMessage("null"), nil, &responseError{Code: PARSE_ERROR, Message: err.Error()})
+			continue
+		}
+		if req.Method == "" {
+			continue // Response to a request of the server, none are sent.
+		}
+		if req.Method == "exit" {
+			if server.isShutdown() {
+				return nil
+			}
+			return errors.New("exit before shutdown")
+		}
+
+		result, err := server.handle(req.Method, req.Params, req.ID != nil)
+		if req.ID != nil {
+			server.respond(*req.ID, result, err)
+		} else if err != nil {
+			log.Printf("%s: %s", req.Method, err)
+		}
+	}
+}
+
+// handle handles the request or notification calling method with params, returning the result.
+func (server *Server) handle(method string, params json.RawMessage, isRequest bool) (interface{}, error) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	switch {
+	case method == "initialize":
+		server.initialized = true
+		return &initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:   textDocumentSyncOptions{OpenClose: true, Change: TEXT_DOCUMENT_SYNC_FULL, Save: true},
+				CodeActionProvider: codeActionOptions{CodeActionKinds: []string{CODE_ACTION_QUICKFIX}},
+			},
+			ServerInfo: serverInfo{Name: globalvars.PROGRAM_NAME, Version: globalvars.VERSION},
+		}, nil
+	case !server.initialized:
+		return nil, &responseError{Code: SERVER_NOT_INITIALIZED, Message: "server is not initialized"}
+	case server.shutdown:
+		return nil, &responseError{Code: INVALID_REQUEST, Message: "server is shut down"}
+	}
+
+	var err error
+	switch method {
+	case "initialized":
+	case "shutdown":
+		server.shutdown = true
+	case "textDocument/didOpen":
+		p := &didOpenTextDocumentParams{}
+		if err = unmarshalParams(params, p); err == nil {
+			err = server.didOpen(p)
+		}
+	case "textDocument/didChange":
+		p := &didChangeTextDocumentParams{}
+		if err = unmarshalParams(params, p); err == nil {
+			server.didChange(p)
+		}
+	case "textDocument/didSave":
+		p := &didSaveTextDocumentParams{}
+		if err = unmarshalParams(params, p); err == nil {
+			server.didSave(p)
+		}
+	case "textDocument/didClose":
+		p := &didCloseTextDocumentParams{}
+		if err = unmarshalParams(params, p); err == nil {
+			server.didClose(p)
+		}
+	case "textDocument/codeAction":
+		p := &codeActionParams{}
+		if err = unmarshalParams(params, p); err != nil {
+			return nil, err
+		}
+		return server.codeActions(p), nil
+	default:
+		if isRequest {
+			return nil, &responseError{Code: METHOD_NOT_FOUND, Message: fmt.Sprintf("method %s is not supported", method)}
+		}
+	}
+	return nil, err
+}
+
+// unmarshalParams decodes params into v, returning an INVALID_PARAMS error if it fails.
+func unmarshalParams(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: INVALID_PARAMS, Message: err.Error()}
+	}
+	return nil
+}
+
+func (server *Server) isShutdown() bool {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.shutdown
+}
+
+func (server *Server) didOpen(params *didOpenTextDocumentParams) error {
+	path, err := uriPath(params.TextDocument.URI)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(path, ".go") {
+		return nil
+	}
+	server.documents[params.TextDocument.URI] = &document{
+		uri:     params.TextDocument.URI,
+		path:    path,
+		version: params.TextDocument.Version,
+		content: []byte(params.TextDocument.Text),
+	}
+	server.scheduleAnalysis(filepath.Dir(path), 0)
+	return nil
+}
+
+func (server *Server) didChange(params *didChangeTextDocumentParams) {
+	doc := server.documents[params.TextDocument.URI]
+	if doc == nil || len(params.ContentChanges) == 0 {
+		return
+	}
+	doc.version = params.TextDocument.Version
+	doc.content = []byte(params.ContentChanges[len(params.ContentChanges)-1].Text)
+	server.scheduleAnalysis(filepath.Dir(doc.path), server.Delay)
+}
+
+func (server *Server) didSave(params *didSaveTextDocumentParams) {
+	if doc := server.documents[params.TextDocument.URI]; doc != nil {
+		server.scheduleAnalysis(filepath.Dir(doc.path), 0) // Files depending on the saved file may change.
+	}
+}
+
+func (server *Server) didClose(params *didCloseTextDocumentParams) {
+	if _, ok := server.documents[params.TextDocument.URI]; !ok {
+		return
+	}
+	delete(server.documents, params.TextDocument.URI)
+	server.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// scheduleAnalysis analyses the package in dir after delay, replacing an analysis scheduled before.
+func (server *Server) scheduleAnalysis(dir string, delay time.Duration) {
+	if timer := server.timers[dir]; timer != nil {
+		timer.Stop()
+	}
+	server.timers[dir] = time.AfterFunc(delay, func() { server.analyse(dir) })
+}
+
+// stopAnalyses stops the scheduled analyses.
+func (server *Server) stopAnalyses() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, timer := range server.timers {
+		timer.Stop()
+	}
+}
+
+// analyse analyses the package in dir, including its tests, with the content of the open documents.
+// Diagnostics are published for the documents in dir not changed during the analysis.
+func (server *Server) analyse(dir string) {
+	server.mu.Lock()
+	var results []*analysis
+	overlay := make(map[string][]byte)
+	for _, doc := range server.documents {
+		overlay[doc.path] = doc.content
+		if realDir, err := filepath.EvalSymlinks(filepath.Dir(doc.path)); err == nil {
+			overlay[filepath.Join(realDir, filepath.Base(doc.path))] = doc.content // As reported by the go command.
+		}
+		if filepath.Dir(doc.path) == dir {
+			results = append(results, &analysis{document: doc, version: doc.version, content: doc.content})
+		}
+	}
+	server.mu.Unlock()
+	if len(results) == 0 {
+		return
+	}
+
+	goFiles, err := analyseDir(dir, overlay)
+	if err != nil {
+		log.Printf("Analysing %s: %s", dir, err)
+		return
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, result := range results {
+		doc := result.document
+		if server.documents[doc.uri] != doc || doc.version != result.version {
+			continue // Changed or closed, a newer analysis is scheduled.
+		}
+		result.goFiles = goFiles[doc.path]
+		doc.result = result
+		server.publishDiagnostics(doc)
+	}
+}
+
+// analyseDir analyses the package in dir and its tests, replacing the content of files by the
+// content in overlay, and returns the files with violations by file path. A panic is returned
+// as an error, keeping the server running.
+func analyseDir(dir string, overlay map[string][]byte) (goFiles map[string][]*linter.GoFile, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			goFiles, err = nil, fmt.Errorf("analysis failed: %v", r)
+		}
+	}()
+	config, err := linter.LoadConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	config.Patterns = []string{"."}
+	config.Builds = []linter.BuildConfig{{Tests: true}}
+	config.Overlay = overlay
+
+	goPackages, err := linter.AnalyzePackages(config)
+	if err != nil {
+		return nil, err
+	}
+	goFiles = make(map[string][]*linter.GoFile)
+	for _, goPackage := range goPackages {
+		for _, diagnostic := range goPackage.Diagnostics {
+			if !diagnostic.Incomplete() { // Parse and type errors are reported by the editor.
+				log.Printf("%s: %s", goPackage.ImportPath, diagnostic)
+			}
+		}
+		for _, goFile := range goPackage.Violations {
+			filePath := filepath.Clean(goFile.FilePath)
+			goFiles[filePath] = append(goFiles[filePath], goFile)
+		}
+	}
+	return goFiles, nil
+}
+
+// publishDiagnostics publishes the violations of the latest analysis of the document.
+func (server *Server) publishDiagnostics(doc *document) {
+	diagnostics := []Diagnostic{}
+	for _, goFile := range doc.result.goFiles {
+		for _, violation := range goFile.Violations {
+			diagnostics = append(diagnostics, newDiagnostic(doc.result.content, violation))
+		}
+	}
+	server.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     doc.result.version,
+		Diagnostics: diagnostics,
+	})
+}
+
+// codeActions returns the suggested fixes of the violations in the range of the document as code
+// actions, none if the document changed since it was analysed. Fixes introducing type errors
+// are left out.
+func (server *Server) codeActions(params *codeActionParams) []CodeAction {
+	codeActions := []CodeAction{}
+	doc := server.documents[params.TextDocument.URI]
+	if doc == nil || doc.result == nil || doc.result.version != doc.version {
+		return codeActions
+	}
+
+	for _, goFile := range doc.result.goFiles {
+		for _, violation := range goFile.Violations {
+			diagnostic := newDiagnostic(doc.result.content, violation)
+			if !overlaps(diagnostic.Range, params.Range) {
+				continue
+			}
+			for i, fix := range goFile.ValidFixes(violation) {
+				var textEdits []TextEdit
+				for _, textEdit := range fix.TextEdits {
+					textEdits = append(textEdits, TextEdit{
+						Range:   offsetRange(doc.result.content, textEdit.StartOffset, textEdit.EndOffset),
+						NewText: textEdit.NewText,
+					})
+				}
+				codeActions = append(codeActions, CodeAction{
+					Title:       fix.Message,
+					Kind:        CODE_ACTION_QUICKFIX,
+					Diagnostics: []Diagnostic{diagnostic},
+					IsPreferred: i == 0,
+					Edit:        WorkspaceEdit{Changes: map[string][]TextEdit{doc.uri: textEdits}},
+				})
+			}
+		}
+	}
+	return codeActions
+}
+
+// respond sends the response to the request with id, having result or err.
+func (server *Server) respond(id json.RawMessage, result interface{}, err error) {
+	resp := &response{JSONRPC: "2.0", ID: id}
+	if err != nil {
+		respErr, ok := err.(*responseError)
+		if !ok {
+			respErr = &responseError{Code: INVALID_REQUEST, Message: err.Error()}
+		}
+		resp.Error = respErr
+	} else if resp.Result, err = json.Marshal(result); err != nil {
+		resp.Result, resp.Error = nil, &responseError{Code: INVALID_REQUEST, Message: err.Error()}
+	}
+	server.write(resp)
+}
+
+// notify sends the notification calling method with params.
+func (server *Server) notify(method string, params interface{}) {
+	server.write(&notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (server *Server) write(message interface{}) {
+	server.writeMu.Lock()
+	defer server.writeMu.Unlock()
+	if err := writeMessage(server.out, message); err != nil {
+		log.Printf("Writing to client: %s", err)
+	}
+}
+
+// newDiagnostic returns the diagnostic of the violation found in the document content.
+func newDiagnostic(content []byte, violation *linter.Violation) Diagnostic {
+	severity := SEVERITY_INFORMATION
+	switch {
+	case violation.Severity >= linter.CRITICAL:
+		severity = SEVERITY_ERROR
+	case violation.Severity >= linter.MINOR:
+		severity = SEVERITY_WARNING
+	}
+	return Diagnostic{
+		Range:    offsetRange(content, violation.StartOffset, violation.EndOffset),
+		Severity: severity,
+		Code:     violation.Type.String(),
+		Source:   globalvars.PROGRAM_NAME,
+		Message:  violation.Description,
+	}
+}
+
+// offsetRange returns the range between the byte offsets start and end in content.
+func offsetRange(content []byte, start int, end int) Range {
+	return Range{Start: offsetPosition(content, start), End: offsetPosition(content, end)}
+}
+
+// offsetPosition returns the position of the byte offset in content, counting characters in
+// UTF-16 code units as LSP does.
+func offsetPosition(content []byte, offset int) Position {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	var position Position
+	for i := 0; i < offset; {
+		r, size := utf8.DecodeRune(content[i:offset])
+		if r == '\n' {
+			position.Line++
+			position.Character = 0
+		} else if n := utf16.RuneLen(r); n > 0 {
+			position.Character += n
+		} else {
+			position.Character++ // Invalid UTF-8 is replaced by U+FFFD.
+		}
+		i += size
+	}
+	return position
+}
+
+// overlaps returns true if the ranges a and b overlap or touch.
+func overlaps(a Range, b Range) bool {
+	before := func(p Position, q Position) bool {
+		return p.Line < q.Line || p.Line == q.Line && p.Character < q.Character
+	}
+	return !before(a.End, b.Start) && !before(b.End, a.Start)
+}
+
+// uriPath returns the absolute file path of a file URI.
+func uriPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI %s, only file URIs are", uri)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
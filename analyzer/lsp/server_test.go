@@ -0,0 +1,184 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package lsp_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/lsp"
+)
+
+const savedSrc = `package main
+
+func main() {
+}
+`
+
+// Unsaved content of main.go, allocating a map with new on line 4 (line 3 in LSP).
+const editedSrc = `package main
+
+func main() {
+	m := new(map[string]int)
+	_ = m
+}
+`
+
+// client talks to a server over pipes.
+type client struct {
+	t      *testing.T
+	in     io.Writer
+	out    *bufio.Reader
+	nextID int
+}
+
+func (c *client) send(message map[string]interface{}) {
+	message["jsonrpc"] = "2.0"
+	content, err := json.Marshal(message)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(content), content); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// receive returns the next message from the server, decoded into v.
+func (c *client) receive(v interface{}) {
+	var contentLength int
+	for {
+		line, err := c.out.ReadString('\n')
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "Content-Length:") {
+			contentLength, _ = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "Content-Length:")))
+		}
+	}
+	content := make([]byte, contentLength)
+	if _, err := io.ReadFull(c.out, content); err != nil {
+		c.t.Fatal(err)
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// request sends a request calling method with params, and decodes the result into result.
+func (c *client) request(method string, params interface{}, result interface{}) {
+	c.nextID++
+	c.send(map[string]interface{}{"id": c.nextID, "method": method, "params": params})
+	var resp struct {
+		ID     int
+		Result json.RawMessage
+		Error  *struct{ Message string }
+	}
+	c.receive(&resp)
+	if resp.Error != nil || resp.ID != c.nextID {
+		c.t.Fatalf("%s should give the result of request %d, not %d with error %v!", method, c.nextID, resp.ID, resp.Error)
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(filePath, []byte(savedSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/server\n\ngo 1.25\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	uri := (&url.URL{Scheme: "file", Path: filepath.ToSlash(filePath)}).String()
+
+	clientIn, serverIn := io.Pipe()
+	serverOut, clientOut := io.Pipe()
+	server := lsp.NewServer()
+	server.Delay = 0
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(clientIn, clientOut) }()
+	c := &client{t: t, in: serverIn, out: bufio.NewReader(serverOut)}
+
+	var initializeResult struct {
+		Capabilities struct {
+			TextDocumentSync struct{ Change int }
+		}
+	}
+	c.request("initialize", map[string]interface{}{}, &initializeResult)
+	if initializeResult.Capabilities.TextDocumentSync.Change != lsp.TEXT_DOCUMENT_SYNC_FULL {
+		t.Errorf("Server should ask for full document synchronisation!")
+	}
+	c.send(map[string]interface{}{"method": "initialized", "params": map[string]interface{}{}})
+
+	// The unsaved content is analysed, not the file on disk.
+	c.send(map[string]interface{}{"method": "textDocument/didOpen", "params": map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "go", "version": 1, "text": editedSrc},
+	}})
+	var published struct {
+		Method string
+		Params struct {
+			URI         string
+			Version     int
+			Diagnostics []lsp.Diagnostic
+		}
+	}
+	c.receive(&published)
+	if published.Method != "textDocument/publishDiagnostics" || published.Params.URI != uri || published.Params.Version != 1 {
+		t.Fatalf("Diagnostics of version 1 of %s should be published, not %+v!", uri, published)
+	}
+	if len(published.Params.Diagnostics) != 1 {
+		t.Fatalf("Number of diagnostics should be 1, but are %d!", len(published.Params.Diagnostics))
+	}
+	diagnostic := published.Params.Diagnostics[0]
+	expectedRange := lsp.Range{Start: lsp.Position{Line: 3, Character: 6}, End: lsp.Position{Line: 3, Character: 25}}
+	if diagnostic.Code != "MAP_ALLOCATED_WITH_NEW" || diagnostic.Range != expectedRange || diagnostic.Severity != lsp.SEVERITY_ERROR {
+		t.Errorf("Diagnostic should be a MAP_ALLOCATED_WITH_NEW error at %v, and not %+v!", expectedRange, diagnostic)
+	}
+
+	// The suggested fix is offered at the violation.
+	var codeActions []lsp.CodeAction
+	c.request("textDocument/codeAction", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"range":        lsp.Range{Start: lsp.Position{Line: 3, Character: 8}, End: lsp.Position{Line: 3, Character: 8}},
+		"context":      map[string]interface{}{"diagnostics": []lsp.Diagnostic{diagnostic}},
+	}, &codeActions)
+	if len(codeActions) != 1 {
+		t.Fatalf("Number of code actions should be 1, but are %d!", len(codeActions))
+	}
+	textEdits := codeActions[0].Edit.Changes[uri]
+	expectedEdit := lsp.TextEdit{Range: lsp.Range{Start: lsp.Position{Line: 3, Character: 6}, End: lsp.Position{Line: 3, Character: 9}}, NewText: "make"}
+	if codeActions[0].Kind != "quickfix" || len(textEdits) != 1 || textEdits[0] != expectedEdit {
+		t.Errorf("Code action should be a quickfix replacing new with make, and not %+v!", codeActions[0])
+	}
+
+	// Fixing the violation clears the diagnostics.
+	c.send(map[string]interface{}{"method": "textDocument/didChange", "params": map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]interface{}{{"text": strings.Replace(editedSrc, "new", "make", 1)}},
+	}})
+	c.receive(&published)
+	if published.Params.Version != 2 || len(published.Params.Diagnostics) != 0 {
+		t.Errorf("Version 2 should have no diagnostics, but version %d has %d!", published.Params.Version, len(published.Params.Diagnostics))
+	}
+
+	var shutdownResult interface{}
+	c.request("shutdown", nil, &shutdownResult)
+	c.send(map[string]interface{}{"method": "exit"})
+	if err := <-served; err != nil {
+		t.Errorf("Server should exit without error after shutdown, not with %s!", err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/globalvars"
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+const CHECKSTYLE_VERSION = "4.3"
+
+type checkstyleReport struct {
+	XMLName xml.Name          `xml:"checkstyle"`
+	Version string            `xml:"version,attr"`
+	Files   []*checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string             `xml:"name,attr"`
+	Errors []*checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// checkstyleSeverity maps the severity of a rule to the checkstyle severity.
+func checkstyleSeverity(severity linter.Severity) string {
+	switch {
+	case severity >= linter.CRITICAL:
+		return "error"
+	case severity >= linter.MINOR:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// WriteCheckstyle writes the violations and diagnostics in goPackages to w as checkstyle
+// XML, one file element per Go source file. Diagnostics are errors, sourced by their kind.
+func WriteCheckstyle(w io.Writer, goPackages []*linter.GoPackage) error {
+	checkstyle := checkstyleReport{Version: CHECKSTYLE_VERSION}
+	for _, goFile := range GroupFindingsByFile(goPackages) {
+		file := &checkstyleFile{Name: goFile.FilePath}
+		for _, diagnostic := range goFile.Diagnostics {
+			file.Errors = append(file.Errors, &checkstyleError{
+				Line:     diagnostic.Line,
+				Column:   diagnostic.Column,
+				Severity: "error",
+				Message:  diagnostic.Message,
+				Source:   fmt.Sprintf("%s.%s", globalvars.PROGRAM_NAME, diagnostic.Kind),
+			})
+		}
+		for _, violation := range goFile.Violations {
+			file.Errors = append(file.Errors, &checkstyleError{
+				Line:     violation.SrcLine,
+				Column:   violation.StartColumn,
+				Severity: checkstyleSeverity(violation.Severity),
+				Message:  violation.Description,
+				Source:   fmt.Sprintf("%s.%s", globalvars.PROGRAM_NAME, violation.Type),
+			})
+		}
+		checkstyle.Files = append(checkstyle.Files, file)
+	}
+	return writeXML(w, checkstyle)
+}
+
+// writeXML writes the XML header followed by the indented XML encoding of v to w.
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
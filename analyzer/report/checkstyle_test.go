@@ -0,0 +1,91 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"sort"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/report"
+)
+
+func TestWriteCheckstyle(t *testing.T) {
+	goPackages := detectViolations(t, "../linter/testcode")
+
+	var buffer bytes.Buffer
+	if err := report.WriteCheckstyle(&buffer, goPackages); err != nil {
+		t.Fatal(err)
+	}
+
+	var checkstyle struct {
+		Files []struct {
+			Name   string `xml:"name,attr"`
+			Errors []struct {
+				Line   int    `xml:"line,attr"`
+				Column int    `xml:"column,attr"`
+				Source string `xml:"source,attr"`
+			} `xml:"error"`
+		} `xml:"file"`
+	}
+	if err := xml.Unmarshal(buffer.Bytes(), &checkstyle); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(checkstyle.Files) == 0 {
+		t.Fatal("There is no files containing violations.")
+	}
+	// Files and their violations must be sorted, so the output is stable between runs.
+	if !sort.SliceIsSorted(checkstyle.Files, func(i, j int) bool { return checkstyle.Files[i].Name < checkstyle.Files[j].Name }) {
+		t.Error("Files should be sorted by name!")
+	}
+	for _, file := range checkstyle.Files {
+		if !sort.SliceIsSorted(file.Errors, func(i, j int) bool { return file.Errors[i].Line < file.Errors[j].Line }) {
+			t.Errorf("Errors in %s should be sorted by line!", file.Name)
+		}
+		for _, checkstyleError := range file.Errors {
+			if checkstyleError.Column < 1 {
+				t.Errorf("Error %s at line %d in %s should have a column!", checkstyleError.Source, checkstyleError.Line, file.Name)
+			}
+		}
+	}
+
+	// Identical runs produce identical reports.
+	var secondBuffer bytes.Buffer
+	if err := report.WriteCheckstyle(&secondBuffer, detectViolations(t, "../linter/testcode")); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), secondBuffer.Bytes()) {
+		t.Error("Two runs on the same code should give identical reports!")
+	}
+}
+
+func TestWriteCheckstyleWithDiagnostics(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := report.WriteCheckstyle(&buffer, brokenPackages(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var checkstyle struct {
+		Files []struct {
+			Errors []struct {
+				Line     int    `xml:"line,attr"`
+				Severity string `xml:"severity,attr"`
+				Source   string `xml:"source,attr"`
+			} `xml:"error"`
+		} `xml:"file"`
+	}
+	if err := xml.Unmarshal(buffer.Bytes(), &checkstyle); err != nil {
+		t.Fatal(err)
+	}
+	if len(checkstyle.Files) != 1 || len(checkstyle.Files[0].Errors) != 1 {
+		t.Fatalf("Report should have 1 file with 1 error, not %v!", checkstyle.Files)
+	}
+	if checkstyleError := checkstyle.Files[0].Errors[0]; checkstyleError.Line != 4 || checkstyleError.Severity != "error" ||
+		checkstyleError.Source != "GoAnalysis.TYPE_ERROR" {
+		t.Errorf("Error should be a GoAnalysis.TYPE_ERROR error at line 4, and not a %s %s at line %d!",
+			checkstyleError.Source, checkstyleError.Severity, checkstyleError.Line)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const DIFF_CONTEXT_LINES = 3 // Number of unchanged lines around the changes in a hunk.
+
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// WriteUnifiedDiff writes the changes from the from to the to content as an unified diff
+// to w, like 'diff -u' does. Nothing is written if the contents are equal.
+func WriteUnifiedDiff(w io.Writer, fromName string, toName string, from []byte, to []byte) error {
+	lines := diffLines(splitLines(string(from)), splitLines(string(to)))
+
+	buffer := bufio.NewWriter(w)
+	headerWritten := false
+	for start := 0; start < len(lines); {
+		// Find the next change, and the end of the hunk containing it.
+		for start < len(lines) && lines[start].op == diffEqual {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+		end := start
+		for equal := 0; end < len(lines) && equal <= 2*DIFF_CONTEXT_LINES; end++ {
+			if lines[end].op == diffEqual {
+				equal++
+			} else {
+				equal = 0
+			}
+		}
+		for end > start && lines[end-1].op == diffEqual {
+			end--
+		}
+
+		hunkStart, hunkEnd := start-DIFF_CONTEXT_LINES, end+DIFF_CONTEXT_LINES
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		if hunkEnd > len(lines) {
+			hunkEnd = len(lines)
+		}
+
+		if !headerWritten {
+			fmt.Fprintf(buffer, "--- %s\n+++ %s\n", fromName, toName)
+			headerWritten = true
+		}
+		fromLine, toLine := lineNumbers(lines[:hunkStart])
+		fromCount, toCount := lineNumbers(lines[hunkStart:hunkEnd])
+		fmt.Fprintf(buffer, "@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount))
+		for _, line := range lines[hunkStart:hunkEnd] {
+			prefix := " "
+			switch line.op {
+			case diffDelete:
+				prefix = "-"
+			case diffInsert:
+				prefix = "+"
+			}
+			buffer.WriteString(prefix + line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				buffer.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = hunkEnd
+	}
+	return buffer.Flush()
+}
+
+// splitLines splits text after each newline, keeping the newlines.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineNumbers counts the lines in the from and to content among lines.
+func lineNumbers(lines []diffLine) (from int, to int) {
+	for _, line := range lines {
+		if line.op != diffInsert {
+			from++
+		}
+		if line.op != diffDelete {
+			to++
+		}
+	}
+	return from, to
+}
+
+// hunkRange formats the range of a hunk, starting after the line before and count lines long.
+func hunkRange(before int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprint(before + 1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// diffLines computes the shortest edit script transforming from into to, using the
+// algorithm in Eugene W. Myers, "An O(ND) Difference Algorithm and Its Variations".
+func diffLines(from []string, to []string) []diffLine {
+	n, m := len(from), len(to)
+	max := n + m
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	// Forward search, recording the furthest reaching path on every diagonal k before each number of edits d.
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[max+k-1] < v[max+k+1] {
+				x = v[max+k+1] // Insertion, move down.
+			} else {
+				x = v[max+k-1] + 1 // Deletion, move right.
+			}
+			y := x - k
+			for x < n && y < m && from[x] == to[y] {
+				x, y = x+1, y+1
+			}
+			v[max+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Backtrack from the end, collecting the edits in reverse.
+	var lines []diffLine
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		k := x - y
+		if d == 0 {
+			for x > 0 {
+				x, y = x-1, y-1
+				lines = append(lines, diffLine{diffEqual, from[x]})
+			}
+			break
+		}
+		v := trace[d]
+		var prevK int
+		if k == -d || k != d && v[max+k-1] < v[max+k+1] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[max+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			lines = append(lines, diffLine{diffEqual, from[x]})
+		}
+		if x == prevX {
+			y--
+			lines = append(lines, diffLine{diffInsert, to[y]})
+		} else {
+			x--
+			lines = append(lines, diffLine{diffDelete, from[x]})
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
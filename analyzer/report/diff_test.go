@@ -0,0 +1,35 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/report"
+)
+
+func TestWriteUnifiedDiff(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	to := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nm\nn\n"
+	expected := "--- a.go\n+++ b.go\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -9,5 +9,5 @@\n i\n j\n k\n-l\n m\n+n\n"
+
+	var buffer bytes.Buffer
+	if err := report.WriteUnifiedDiff(&buffer, "a.go", "b.go", []byte(from), []byte(to)); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != expected {
+		t.Errorf("Diff should be:\n%s\nand not:\n%s", expected, buffer.String())
+	}
+
+	buffer.Reset()
+	if err := report.WriteUnifiedDiff(&buffer, "a.go", "b.go", []byte(from), []byte(from)); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.Len() != 0 {
+		t.Errorf("Diff of equal content should be empty, and not:\n%s", buffer.String())
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/globalvars"
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+const HTML_INDEX_FILE = "index.html"
+
+type htmlIndex struct {
+	Program     string
+	Version     string
+	Root        string
+	Files       int
+	Violations  int
+	Diagnostics int
+	Packages    []*htmlPackage
+}
+
+type htmlPackage struct {
+	Name        string
+	Path        string
+	Files       []*htmlFile
+	Diagnostics []*linter.Diagnostic
+}
+
+type htmlFile struct {
+	Program         string
+	Name            string
+	Link            string // Location of the file page, relative to the index.
+	IndexLink       string // Location of the index, relative to the file page.
+	LinesOfCode     int
+	LinesOfComments int
+	Violations      int
+	MaxComplexity   int
+	ComplexityLimit int
+	Functions       []*htmlFunction
+	Lines           []*htmlLine
+}
+
+type htmlFunction struct {
+	Name       string
+	SrcLine    int
+	Complexity int
+	Nodes      int
+	Edges      int
+	OverLimit  bool
+	SVG        template.HTML
+}
+
+type htmlLine struct {
+	Number     int
+	Text       string
+	Violations []*linter.Violation
+}
+
+var htmlTemplates = template.Must(template.New("html").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}).Parse(htmlTemplateText))
+
+// WriteHTML writes a static, self-contained HTML report of goPackages to outDir: an
+// index page with the package and file tree, and a page per Go source file with the
+// annotated source listing, the cyclomatic complexity of every function and a drawing
+// of its control-flow graph. File pages mirror the file location relative to rootDir.
+// Diagnostics are listed with their package on the index page.
+func WriteHTML(outDir string, goPackages []*linter.GoPackage, rootDir string) error {
+	absRootDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return err
+	}
+
+	index := &htmlIndex{Program: globalvars.PROGRAM_NAME, Version: globalvars.VERSION, Root: rootDir}
+	for _, goPackage := range goPackages {
+		pkg := &htmlPackage{Name: goPackage.Pack.Name, Path: goPackage.Path, Diagnostics: goPackage.Diagnostics}
+		index.Diagnostics += len(goPackage.Diagnostics)
+		for _, goFile := range goPackage.Files {
+			file, err := newHTMLFile(goFile, relativePagePath(absRootDir, goFile.FilePath))
+			if err != nil {
+				return err
+			}
+			if err := writeHTMLPage(filepath.Join(outDir, filepath.FromSlash(file.Link)), "file", file); err != nil {
+				return err
+			}
+			file.Lines, file.Functions = nil, nil // Only needed by the file page.
+			pkg.Files = append(pkg.Files, file)
+			index.Files++
+			index.Violations += file.Violations
+		}
+		index.Packages = append(index.Packages, pkg)
+	}
+	return writeHTMLPage(filepath.Join(outDir, HTML_INDEX_FILE), "index", index)
+}
+
+// newHTMLFile reads the source of goFile and measures the cyclomatic complexity of its
+// functions, returning the content of the file page located at pagePath.
+func newHTMLFile(goFile *linter.GoFile, pagePath string) (*htmlFile, error) {
+	src, err := ioutil.ReadFile(goFile.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &htmlFile{
+		Program:         globalvars.PROGRAM_NAME,
+		Name:            pagePath,
+		Link:            pagePath + ".html",
+		IndexLink:       strings.Repeat("../", strings.Count(pagePath, "/")) + HTML_INDEX_FILE,
+		LinesOfCode:     goFile.LinesOfCode,
+		LinesOfComments: goFile.LinesOfComments,
+		Violations:      len(goFile.Violations),
+		ComplexityLimit: linter.CC_LIMIT,
+	}
+	if goFile.Config() != nil {
+		file.ComplexityLimit = goFile.Config().IntParam(linter.CYCLOMATIC_COMPLEXITY, "limit", linter.CC_LIMIT)
+	}
+
+	violations := make(map[int][]*linter.Violation)
+	sortedViolations := append([]*linter.Violation(nil), goFile.Violations...)
+	sort.Stable(byLine(sortedViolations))
+	for _, violation := range sortedViolations {
+		violations[violation.SrcLine] = append(violations[violation.SrcLine], violation)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(nil, len(src)+1)
+	for scanner.Scan() {
+		number := len(file.Lines) + 1
+		file.Lines = append(file.Lines, &htmlLine{Number: number, Text: scanner.Text(), Violations: violations[number]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, function := range goFile.Complexity() {
+		var svg bytes.Buffer
+		if err := function.ControlFlowGraph.WriteSVG(&svg); err != nil {
+			return nil, err
+		}
+		file.Functions = append(file.Functions, &htmlFunction{
+			Name:       function.Name,
+			SrcLine:    function.SrcLine,
+			Complexity: function.Complexity,
+			Nodes:      function.GetNumberOfNodes(),
+			Edges:      function.GetNumberOfEdges(),
+			OverLimit:  function.Complexity > file.ComplexityLimit,
+			SVG:        template.HTML(svg.String()), // Generated by WriteSVG with escaped labels.
+		})
+		if function.Complexity > file.MaxComplexity {
+			file.MaxComplexity = function.Complexity
+		}
+	}
+	return file, nil
+}
+
+// relativePagePath returns the slash separated location of the page of filePath in the
+// report, being the path relative to absRootDir, or below 'external' if outside it.
+func relativePagePath(absRootDir string, filePath string) string {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		absPath = filePath
+	}
+	if relPath, err := filepath.Rel(absRootDir, absPath); err == nil && !strings.HasPrefix(relPath, "..") {
+		return filepath.ToSlash(relPath)
+	}
+	return "external/" + strings.TrimLeft(filepath.ToSlash(strings.TrimPrefix(absPath, filepath.VolumeName(absPath))), "/")
+}
+
+// writeHTMLPage executes the named template with data, writing the result to the file path.
+func writeHTMLPage(path string, name string, data interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var page bytes.Buffer
+	if err := htmlTemplates.ExecuteTemplate(&page, name, data); err != nil {
+		return fmt.Errorf("writing %s: %s", path, err)
+	}
+	return ioutil.WriteFile(path, page.Bytes(), 0644)
+}
+
+const htmlTemplateText = `
+{{define "style"}}<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { padding: 0.2em 0.8em; text-align: left; border-bottom: 1px solid #ddd; }
+th { background: #f0f0f0; }
+td.number { text-align: right; }
+.over-limit { color: #b00; font-weight: bold; }
+ul.diagnostics { color: #b00; }
+table.source td { border: none; padding: 0 0.8em; }
+table.source td.line { color: #888; text-align: right; user-select: none; }
+table.source pre { margin: 0; tab-size: 4; }
+tr.has-violation { background: #fff3cd; }
+tr.violation td { font-size: 0.9em; padding: 0.2em 0.8em 0.4em 3em; }
+.severity-blocker, .severity-critical { color: #b00; }
+.severity-major { color: #c60; }
+.severity-minor { color: #870; }
+.severity-info { color: #07a; }
+figure { margin: 0 0 2em 0; }
+figure svg { max-width: 100%; height: auto; }
+</style>{{end}}
+
+{{define "index"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Program}} report</title>
+{{template "style"}}
+</head>
+<body>
+<h1>{{.Program}} report</h1>
+<p>Analysed {{.Files}} files in {{.Root}}, found {{.Violations}} violations{{if .Diagnostics}} and {{.Diagnostics}} diagnostics{{end}}. Generated by {{.Program}} {{.Version}}.</p>
+{{range .Packages}}<h2>Package {{.Name}}</h2>
+<p>{{.Path}}</p>
+{{with .Diagnostics}}<ul class="diagnostics">
+{{range .}}<li>{{.}}{{with .Builds}} [{{join . "; "}}]{{end}}</li>
+{{end}}</ul>
+{{end}}<table>
+<tr><th>File</th><th>Lines of code</th><th>Lines of comments</th><th>Violations</th><th>Highest complexity</th></tr>
+{{range .Files}}<tr><td><a href="{{.Link}}">{{.Name}}</a></td><td class="number">{{.LinesOfCode}}</td><td class="number">{{.LinesOfComments}}</td><td class="number">{{.Violations}}</td><td class="number{{if gt .MaxComplexity .ComplexityLimit}} over-limit{{end}}">{{.MaxComplexity}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+{{end}}
+
+{{define "file"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}} - {{.Program}}</title>
+{{template "style"}}
+</head>
+<body>
+<p><a href="{{.IndexLink}}">Index</a></p>
+<h1>{{.Name}}</h1>
+<p>{{.LinesOfCode}} lines of code, {{.LinesOfComments}} lines of comments, {{.Violations}} violations.</p>
+<h2>Cyclomatic complexity</h2>
+<table>
+<tr><th>Function</th><th>Line</th><th>Complexity</th><th>Nodes</th><th>Edges</th></tr>
+{{range $index, $function := .Functions}}<tr><td><a href="#cfg-{{$index}}">{{.Name}}</a></td><td class="number"><a href="#L{{.SrcLine}}">{{.SrcLine}}</a></td><td class="number{{if .OverLimit}} over-limit{{end}}">{{.Complexity}}</td><td class="number">{{.Nodes}}</td><td class="number">{{.Edges}}</td></tr>
+{{end}}</table>
+<h2>Source</h2>
+<table class="source">
+{{range .Lines}}<tr id="L{{.Number}}"{{if .Violations}} class="has-violation"{{end}}><td class="line"><a href="#L{{.Number}}">{{.Number}}</a></td><td><pre>{{.Text}}</pre></td></tr>
+{{range .Violations}}<tr class="violation"><td></td><td class="severity-{{lower .Severity.String}}">{{.Type}} ({{.Severity}}) at column {{.StartColumn}}: {{.Description}}{{with .Builds}} [{{join . "; "}}]{{end}}</td></tr>
+{{end}}{{end}}</table>
+<h2>Control-flow graphs</h2>
+{{range $index, $function := .Functions}}<figure id="cfg-{{$index}}">
+<figcaption>{{.Name}}() at line {{.SrcLine}}, complexity {{.Complexity}}</figcaption>
+{{.SVG}}
+</figure>
+{{end}}</body>
+</html>
+{{end}}
+`
@@ -0,0 +1,87 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+	"github.com/chrisbbe/GoAnalysis/analyzer/report"
+)
+
+func TestWriteHTML(t *testing.T) {
+	rootDir := "../linter/testcode/cyclomaticomplexity"
+	goPackages, err := linter.AnalyzePackages(linter.NewConfig(rootDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outDir, err := ioutil.TempDir("", "goanalysis-html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outDir)
+
+	if err := report.WriteHTML(outDir, goPackages, rootDir); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := ioutil.ReadFile(filepath.Join(outDir, report.HTML_INDEX_FILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), `<a href="main.go.html">main.go</a>`) {
+		t.Errorf("Index should link to the page of main.go, but is:\n%s", index)
+	}
+
+	page, err := ioutil.ReadFile(filepath.Join(outDir, "main.go.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<a href="index.html">`,               // Link back to the index.
+		`<tr id="L16" class="has-violation">`, // Violation marked in the source listing.
+		"CYCLOMATIC_COMPLEXITY (MAJOR)",       // Violation shown inline.
+		`<a href="#L16">16</a>`,               // Function in the complexity table.
+		"<svg ",                               // Control-flow graph drawing.
+	} {
+		if !strings.Contains(string(page), expected) {
+			t.Errorf("Page of main.go should contain %q!", expected)
+		}
+	}
+}
+
+// Functions starting on the same line have their own control-flow graph anchor.
+func TestWriteHTMLGraphAnchors(t *testing.T) {
+	dir := t.TempDir()
+	src := "package main\n\nfunc main() { first := func() {}; second := func() {}; first(); second() }\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/anchors\n\ngo 1.25\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	goPackages, err := linter.AnalyzePackages(linter.NewConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := t.TempDir()
+	if err := report.WriteHTML(outDir, goPackages, dir); err != nil {
+		t.Fatal(err)
+	}
+	page, err := ioutil.ReadFile(filepath.Join(outDir, "main.go.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, anchor := range []string{"cfg-0", "cfg-1", "cfg-2"} {
+		if strings.Count(string(page), `id="`+anchor+`"`) != 1 || !strings.Contains(string(page), `href="#`+anchor+`"`) {
+			t.Errorf("Page of main.go should have one graph with anchor %s linked from the complexity table!", anchor)
+		}
+	}
+}
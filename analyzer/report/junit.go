@@ -0,0 +1,106 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/globalvars"
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+type junitTestSuites struct {
+	XMLName    xml.Name          `xml:"testsuites"`
+	Name       string            `xml:"name,attr"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	TestSuites []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the violations and diagnostics in goPackages to w as JUnit XML, having
+// one test suite per Go source file and one failed test case per violation or diagnostic.
+// A single passed test case is written if there are none, so the report is never empty.
+func WriteJUnit(w io.Writer, goPackages []*linter.GoPackage) error {
+	testSuites := junitTestSuites{Name: globalvars.PROGRAM_NAME}
+	for _, goFile := range GroupFindingsByFile(goPackages) {
+		testSuite := &junitTestSuite{Name: goFile.FilePath}
+		for _, diagnostic := range goFile.Diagnostics {
+			testSuite.TestCases = append(testSuite.TestCases, &junitTestCase{
+				Name:      fmt.Sprintf("%s (Line %d)", diagnostic.Kind, diagnostic.Line),
+				ClassName: goFile.FilePath,
+				Failure: &junitFailure{
+					Message: diagnostic.Message,
+					Type:    string(diagnostic.Kind),
+					Text:    diagnosticText(diagnostic),
+				},
+			})
+		}
+		for _, violation := range goFile.Violations {
+			testSuite.TestCases = append(testSuite.TestCases, &junitTestCase{
+				Name:      fmt.Sprintf("%s (Line %d)", violation.Type, violation.SrcLine),
+				ClassName: goFile.FilePath,
+				Failure: &junitFailure{
+					Message: violation.Description,
+					Type:    violation.Severity.String(),
+					Text:    junitText(goFile.GoFile, violation),
+				},
+			})
+		}
+		testSuite.Tests = len(testSuite.TestCases)
+		testSuite.Failures = len(testSuite.TestCases)
+		testSuites.TestSuites = append(testSuites.TestSuites, testSuite)
+		testSuites.Tests += testSuite.Tests
+		testSuites.Failures += testSuite.Failures
+	}
+
+	if len(testSuites.TestSuites) == 0 {
+		testSuites.Tests = 1
+		testSuites.TestSuites = append(testSuites.TestSuites, &junitTestSuite{
+			Name:      globalvars.PROGRAM_NAME,
+			Tests:     1,
+			TestCases: []*junitTestCase{{Name: "No violations", ClassName: globalvars.PROGRAM_NAME}},
+		})
+	}
+	return writeXML(w, testSuites)
+}
+
+// junitText returns the failure text of the violation, listing the build configurations it was found under.
+func junitText(goFile *linter.GoFile, violation *linter.Violation) string {
+	text := fmt.Sprintf("%s:%d:%d: %s", goFile.FilePath, violation.SrcLine, violation.StartColumn, violation)
+	if len(violation.Builds) > 0 {
+		text += fmt.Sprintf("\nBuilds: %s", strings.Join(violation.Builds, "; "))
+	}
+	return text
+}
+
+// diagnosticText returns the failure text of the diagnostic, listing the build configurations it was found under.
+func diagnosticText(diagnostic *linter.Diagnostic) string {
+	text := diagnostic.String()
+	if len(diagnostic.Builds) > 0 {
+		text += fmt.Sprintf("\nBuilds: %s", strings.Join(diagnostic.Builds, "; "))
+	}
+	return text
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/report"
+)
+
+type junitReport struct {
+	Tests      int `xml:"tests,attr"`
+	Failures   int `xml:"failures,attr"`
+	TestSuites []struct {
+		Name      string `xml:"name,attr"`
+		TestCases []struct {
+			Name    string `xml:"name,attr"`
+			Failure *struct {
+				Type string `xml:"type,attr"`
+			} `xml:"failure"`
+		} `xml:"testcase"`
+	} `xml:"testsuite"`
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := report.WriteJUnit(&buffer, detectViolations(t, "../linter/testcode/emptyforbody")); err != nil {
+		t.Fatal(err)
+	}
+
+	var junit junitReport
+	if err := xml.Unmarshal(buffer.Bytes(), &junit); err != nil {
+		t.Fatal(err)
+	}
+	if junit.Tests != 1 || junit.Failures != 1 {
+		t.Fatalf("Report should have 1 test and 1 failure, but has %d tests and %d failures!", junit.Tests, junit.Failures)
+	}
+	testCase := junit.TestSuites[0].TestCases[0]
+	if testCase.Name != "EMPTY_FOR_BODY (Line 8)" || testCase.Failure == nil || testCase.Failure.Type != "MINOR" {
+		t.Errorf("Test case %s should be a failed EMPTY_FOR_BODY (Line 8) of type MINOR!", testCase.Name)
+	}
+}
+
+func TestWriteJUnitWithoutViolations(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := report.WriteJUnit(&buffer, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var junit junitReport
+	if err := xml.Unmarshal(buffer.Bytes(), &junit); err != nil {
+		t.Fatal(err)
+	}
+	if junit.Tests != 1 || junit.Failures != 0 {
+		t.Fatalf("Report should have 1 passed test, but has %d tests and %d failures!", junit.Tests, junit.Failures)
+	}
+}
+
+func TestWriteJUnitWithDiagnostics(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := report.WriteJUnit(&buffer, brokenPackages(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var junit junitReport
+	if err := xml.Unmarshal(buffer.Bytes(), &junit); err != nil {
+		t.Fatal(err)
+	}
+	if junit.Tests != 1 || junit.Failures != 1 {
+		t.Fatalf("Report should have 1 test and 1 failure, but has %d tests and %d failures!", junit.Tests, junit.Failures)
+	}
+	testCase := junit.TestSuites[0].TestCases[0]
+	if testCase.Name != "TYPE_ERROR (Line 4)" || testCase.Failure == nil || testCase.Failure.Type != "TYPE_ERROR" {
+		t.Errorf("Test case %s should be a failed TYPE_ERROR (Line 4) of type TYPE_ERROR!", testCase.Name)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"go/ast"
+	"io"
+	"strconv"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+// METRICS_CSV_HEADER is the header row of the CSV metrics, every row describes a package,
+// a file or a function as told by the level column. Columns not applying to the level are empty.
+var METRICS_CSV_HEADER = []string{"level", "package", "file", "function", "line", "lines_of_code",
+	"lines_of_comments", "blank_lines", "functions", "complexity", "basic_blocks"}
+
+// PackageMetrics holds the size of a package, summed over its files.
+type PackageMetrics struct {
+	Path              string // Directory of the package.
+	ImportPath        string
+	Name              string
+	LinesOfCode       int
+	LinesOfComments   int
+	BlankLines        int
+	NumberOfFunctions int
+	Files             []*FileMetrics
+}
+
+// FileMetrics holds the size of a Go source file and the metrics of its functions.
+type FileMetrics struct {
+	FilePath          string
+	LinesOfCode       int
+	LinesOfComments   int
+	BlankLines        int
+	NumberOfFunctions int                // Functions and methods declared, function literals are not counted.
+	Functions         []*FunctionMetrics // Also holds function literals.
+}
+
+// FunctionMetrics holds the complexity of a function or method.
+type FunctionMetrics struct {
+	Name        string
+	SrcLine     int // Line the function is declared at.
+	Complexity  int // Cyclomatic complexity.
+	BasicBlocks int // Number of basic blocks in the control-flow graph.
+}
+
+// Metrics returns the metrics of the analysed packages and their files, in the order of goPackages.
+func Metrics(goPackages []*linter.GoPackage) []*PackageMetrics {
+	var packageMetrics []*PackageMetrics
+	for _, goPackage := range goPackages {
+		if len(goPackage.Files) == 0 {
+			continue // Not loaded, see its diagnostics.
+		}
+		pkg := &PackageMetrics{Path: goPackage.Path, ImportPath: goPackage.ImportPath, Name: goPackage.Pack.Name}
+		for _, goFile := range goPackage.Files {
+			file := &FileMetrics{
+				FilePath:        goFile.FilePath,
+				LinesOfCode:     goFile.LinesOfCode,
+				LinesOfComments: goFile.LinesOfComments,
+				BlankLines:      goFile.BlankLines,
+				Functions:       []*FunctionMetrics{},
+			}
+			for _, funCC := range goFile.Complexity() {
+				file.Functions = append(file.Functions, &FunctionMetrics{
+					Name:        funCC.Name,
+					SrcLine:     funCC.SrcLine,
+					Complexity:  funCC.Complexity,
+					BasicBlocks: funCC.GetNumberOfNodes(),
+				})
+				if _, ok := funCC.Function.(*ast.FuncDecl); ok {
+					file.NumberOfFunctions++
+				}
+			}
+
+			pkg.LinesOfCode += file.LinesOfCode
+			pkg.LinesOfComments += file.LinesOfComments
+			pkg.BlankLines += file.BlankLines
+			pkg.NumberOfFunctions += file.NumberOfFunctions
+			pkg.Files = append(pkg.Files, file)
+		}
+		packageMetrics = append(packageMetrics, pkg)
+	}
+	return packageMetrics
+}
+
+// WriteMetricsJSON writes the metrics of goPackages to w as JSON, a list of packages holding
+// their files, holding their functions.
+func WriteMetricsJSON(w io.Writer, goPackages []*linter.GoPackage) error {
+	packageMetrics := Metrics(goPackages)
+	if packageMetrics == nil {
+		packageMetrics = []*PackageMetrics{}
+	}
+	content, err := json.MarshalIndent(packageMetrics, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// WriteMetricsCSV writes the metrics of goPackages to w as CSV with METRICS_CSV_HEADER, every
+// package is followed by its files, every file by its functions.
+func WriteMetricsCSV(w io.Writer, goPackages []*linter.GoPackage) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(METRICS_CSV_HEADER); err != nil {
+		return err
+	}
+	itoa := strconv.Itoa
+	for _, pkg := range Metrics(goPackages) {
+		csvWriter.Write([]string{"package", pkg.ImportPath, "", "", "", itoa(pkg.LinesOfCode),
+			itoa(pkg.LinesOfComments), itoa(pkg.BlankLines), itoa(pkg.NumberOfFunctions), "", ""})
+		for _, file := range pkg.Files {
+			csvWriter.Write([]string{"file", pkg.ImportPath, file.FilePath, "", "", itoa(file.LinesOfCode),
+				itoa(file.LinesOfComments), itoa(file.BlankLines), itoa(file.NumberOfFunctions), "", ""})
+			for _, function := range file.Functions {
+				csvWriter.Write([]string{"function", pkg.ImportPath, file.FilePath, function.Name, itoa(function.SrcLine),
+					"", "", "", "", itoa(function.Complexity), itoa(function.BasicBlocks)})
+			}
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+	"github.com/chrisbbe/GoAnalysis/analyzer/report"
+)
+
+func TestMetrics(t *testing.T) {
+	goPackages, err := linter.AnalyzePackages(linter.NewConfig("../linter/testcode/cyclomaticomplexity"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	if err := report.WriteMetricsJSON(&buffer, goPackages); err != nil {
+		t.Fatal(err)
+	}
+	var packageMetrics []*report.PackageMetrics
+	if err := json.Unmarshal(buffer.Bytes(), &packageMetrics); err != nil {
+		t.Fatal(err)
+	}
+	if len(packageMetrics) != 1 || len(packageMetrics[0].Files) != 1 {
+		t.Fatalf("Metrics should hold 1 package with 1 file, but are %+v!", packageMetrics)
+	}
+
+	pkg, file := packageMetrics[0], packageMetrics[0].Files[0]
+	if file.LinesOfCode != 36 || file.LinesOfComments != 6 || file.BlankLines != 3 || file.NumberOfFunctions != 2 {
+		t.Errorf("File should have 36 lines of code, 6 of comments, 3 blank and 2 functions, not %+v!", file)
+	}
+	if pkg.LinesOfCode != file.LinesOfCode || pkg.NumberOfFunctions != file.NumberOfFunctions {
+		t.Errorf("Package metrics should sum up its files, but are %+v!", pkg)
+	}
+	expectedFunctions := []*report.FunctionMetrics{
+		{Name: "main", SrcLine: 11, Complexity: 1, BasicBlocks: 3},
+		{Name: "monthNumberToString", SrcLine: 16, Complexity: 13, BasicBlocks: 17},
+	}
+	if !reflect.DeepEqual(file.Functions, expectedFunctions) {
+		t.Errorf("Function metrics should be %+v, but are %+v!", expectedFunctions, file.Functions)
+	}
+}
+
+// Function literals have metrics of their own, but are not counted as functions.
+func TestMetricsOfFunctionLiterals(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/literals\n\ngo 1.25\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := "package main\n\ntype T struct{}\n\nfunc (T) M() {}\n\nfunc main() {\n\tf := func() {}\n\tf()\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	goPackages, err := linter.AnalyzePackages(linter.NewConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packageMetrics := report.Metrics(goPackages)
+	if len(packageMetrics) != 1 || len(packageMetrics[0].Files) != 1 {
+		t.Fatalf("Metrics should hold 1 package with 1 file, but are %+v!", packageMetrics)
+	}
+	pkg, file := packageMetrics[0], packageMetrics[0].Files[0]
+	if len(file.Functions) != 3 || file.NumberOfFunctions != 2 || pkg.NumberOfFunctions != 2 {
+		t.Errorf("File should have metrics of 3 functions and count 2, not %+v!", file)
+	}
+}
+
+func TestWriteMetricsCSV(t *testing.T) {
+	goPackages, err := linter.AnalyzePackages(linter.NewConfig("../linter/testcode/cyclomaticomplexity"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	if err := report.WriteMetricsCSV(&buffer, goPackages); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 || !reflect.DeepEqual(records[0], report.METRICS_CSV_HEADER) {
+		t.Fatalf("CSV should have a header, a package, a file and 2 function rows, but is %v!", records)
+	}
+	for i, level := range []string{"package", "file", "function", "function"} {
+		if records[i+1][0] != level {
+			t.Errorf("Row %d should be a %s row, not %v!", i+1, level, records[i+1])
+		}
+	}
+	if function := records[4]; function[3] != "monthNumberToString" || function[9] != "13" || function[10] != "17" {
+		t.Errorf("Last row should be monthNumberToString with complexity 13 and 17 basic blocks, not %v!", function)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+
+// Package report writes the violations and diagnostics found by the linter in the different output formats.
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+// GroupByFile aggregates the violations in goPackages per Go source file, returning
+// the files sorted by path and their violations sorted by line. The returned files are
+// copies, the files in goPackages are left unchanged.
+func GroupByFile(goPackages []*linter.GoPackage) []*linter.GoFile {
+	violationsMap := make(map[string]*linter.GoFile)
+	var goFiles []*linter.GoFile
+	for _, goPackage := range goPackages {
+		for _, goFile := range goPackage.Violations {
+			if gf, ok := violationsMap[goFile.FilePath]; ok {
+				gf.Violations = append(gf.Violations, goFile.Violations...)
+			} else {
+				gf := *goFile
+				gf.Violations = append([]*linter.Violation(nil), goFile.Violations...)
+				violationsMap[goFile.FilePath] = &gf
+				goFiles = append(goFiles, &gf)
+			}
+		}
+	}
+
+	sort.Sort(byFilePath(goFiles))
+	for _, goFile := range goFiles {
+		sort.Stable(byLine(goFile.Violations))
+	}
+	return goFiles
+}
+
+// FileFindings holds the violations and the diagnostics of a Go source file.
+type FileFindings struct {
+	*linter.GoFile
+	Diagnostics []*linter.Diagnostic `json:",omitempty"`
+}
+
+// GroupFindingsByFile aggregates the violations and diagnostics in goPackages per Go source
+// file like GroupByFile. Diagnostics not located in a file are grouped by the package path.
+func GroupFindingsByFile(goPackages []*linter.GoPackage) []*FileFindings {
+	var files []*FileFindings
+	filesMap := make(map[string]*FileFindings)
+	for _, goFile := range GroupByFile(goPackages) {
+		filesMap[goFile.FilePath] = &FileFindings{GoFile: goFile}
+		files = append(files, filesMap[goFile.FilePath])
+	}
+
+	for _, goPackage := range goPackages {
+		for _, diagnostic := range goPackage.Diagnostics {
+			filePath := diagnostic.FilePath
+			if filePath == "" {
+				filePath = goPackage.Path
+			}
+			file, ok := filesMap[filePath]
+			if !ok {
+				file = &FileFindings{GoFile: &linter.GoFile{FilePath: filePath}}
+				filesMap[filePath] = file
+				files = append(files, file)
+			}
+			file.Diagnostics = append(file.Diagnostics, diagnostic)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].FilePath < files[j].FilePath })
+	for _, file := range files {
+		sort.SliceStable(file.Diagnostics, func(i, j int) bool {
+			if file.Diagnostics[i].Line != file.Diagnostics[j].Line {
+				return file.Diagnostics[i].Line < file.Diagnostics[j].Line
+			}
+			return file.Diagnostics[i].Column < file.Diagnostics[j].Column
+		})
+	}
+	return files
+}
+
+// WriteJSON writes the violations and diagnostics in goPackages grouped per Go source file
+// to w as JSON. Nothing is written if there are none.
+func WriteJSON(w io.Writer, goPackages []*linter.GoPackage) error {
+	files := GroupFindingsByFile(goPackages)
+	if len(files) == 0 {
+		return nil
+	}
+	content, err := json.MarshalIndent(files, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+type byFilePath []*linter.GoFile
+
+func (files byFilePath) Len() int           { return len(files) }
+func (files byFilePath) Swap(i, j int)      { files[i], files[j] = files[j], files[i] }
+func (files byFilePath) Less(i, j int) bool { return files[i].FilePath < files[j].FilePath }
+
+type byLine []*linter.Violation
+
+func (violations byLine) Len() int      { return len(violations) }
+func (violations byLine) Swap(i, j int) { violations[i], violations[j] = violations[j], violations[i] }
+func (violations byLine) Less(i, j int) bool {
+	if violations[i].SrcLine != violations[j].SrcLine {
+		return violations[i].SrcLine < violations[j].SrcLine
+	}
+	return violations[i].Type < violations[j].Type
+}
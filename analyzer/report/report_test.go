@@ -0,0 +1,35 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report_test
+
+import (
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+	"github.com/chrisbbe/GoAnalysis/analyzer/report"
+)
+
+// A file in several packages, like a package and its test variant, is grouped into a new file,
+// so grouping again gives the same result.
+func TestGroupByFileLeavesPackagesUnchanged(t *testing.T) {
+	goPackages := []*linter.GoPackage{
+		{Violations: []*linter.GoFile{{FilePath: "main.go", Violations: []*linter.Violation{{SrcLine: 5, StartLine: 5}}}}},
+		{Violations: []*linter.GoFile{{FilePath: "main.go", Violations: []*linter.Violation{{SrcLine: 3, StartLine: 3}}}}},
+	}
+
+	for i := 0; i < 2; i++ {
+		goFiles := report.GroupByFile(goPackages)
+		if len(goFiles) != 1 || len(goFiles[0].Violations) != 2 || goFiles[0].Violations[0].SrcLine != 3 {
+			t.Fatalf("Grouping %d should give main.go with the violations at line 3 and 5!", i+1)
+		}
+	}
+	for _, goPackage := range goPackages {
+		if len(goPackage.Violations[0].Violations) != 1 {
+			t.Errorf("File in package should keep its violation, but has %d!", len(goPackage.Violations[0].Violations))
+		}
+	}
+	if goPackages[0].Violations[0].Violations[0].SrcLine != 5 {
+		t.Error("Violations of the file in the package should not be reordered!")
+	}
+}
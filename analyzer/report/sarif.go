@@ -0,0 +1,370 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/globalvars"
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+)
+
+const (
+	SARIF_VERSION = "2.1.0"
+	SARIF_SCHEMA  = "https://json.schemastore.org/sarif-2.1.0.json"
+	SARIF_SRCROOT = "SRCROOT" // Base id of the analysed directory, which artifact locations are relative to.
+
+	SARIF_COLUMN_KIND = "unicodeCodePoints" // Columns count Unicode code points, not the default UTF-16 code units.
+)
+
+// The SARIF types only hold the subset of the SARIF 2.1.0 object model written by the analyzer,
+// see https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                         `json:"tool"`
+	Invocations        []*sarifInvocation                `json:"invocations"`
+	OriginalUriBaseIds map[string]*sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	ColumnKind         string                            `json:"columnKind"`
+	Results            []*sarifResult                    `json:"results"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                 `json:"executionSuccessful"`
+	ToolExecutionNotifications []*sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Descriptor sarifDescriptorReference     `json:"descriptor"`
+	Level      string                       `json:"level"`
+	Message    sarifMessage                 `json:"message"`
+	Locations  []*sarifNotificationLocation `json:"locations,omitempty"`
+	Properties *sarifResultProperties       `json:"properties,omitempty"`
+}
+
+type sarifNotificationLocation struct {
+	PhysicalLocation sarifNotificationPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifNotificationPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifLineRegion      `json:"region,omitempty"`
+}
+
+// sarifLineRegion is a region known by line and column only, the column is omitted if unknown.
+type sarifLineRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifDescriptorReference struct {
+	Id string `json:"id"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string                      `json:"name"`
+	Version        string                      `json:"version"`
+	InformationUri string                      `json:"informationUri"`
+	Rules          []*sarifReportingDescriptor `json:"rules"`
+}
+
+type sarifReportingDescriptor struct {
+	Id                   string              `json:"id"`
+	Name                 string              `json:"name"`
+	ShortDescription     sarifMessage        `json:"shortDescription"`
+	FullDescription      sarifMessage        `json:"fullDescription"`
+	HelpUri              string              `json:"helpUri"`
+	DefaultConfiguration sarifConfiguration  `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProperties struct {
+	Severity string   `json:"severity"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleId     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []*sarifLocation       `json:"locations"`
+	Fixes      []*sarifFix            `json:"fixes,omitempty"`
+	Properties *sarifResultProperties `json:"properties,omitempty"`
+}
+
+type sarifResultProperties struct {
+	Builds []string `json:"builds"` // Build configurations the violation was found under.
+}
+
+type sarifFix struct {
+	Description     sarifMessage           `json:"description"`
+	ArtifactChanges []*sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []*sarifReplacement   `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifByteRegion       `json:"deletedRegion"`
+	InsertedContent *sarifArtifactContent `json:"insertedContent,omitempty"`
+}
+
+type sarifByteRegion struct {
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+}
+
+type sarifArtifactContent struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	Uri       string `json:"uri"`
+	UriBaseId string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+	ByteOffset  int `json:"byteOffset"`
+	ByteLength  int `json:"byteLength"`
+}
+
+// sarifLevel maps the severity of a rule to the SARIF result level.
+func sarifLevel(severity linter.Severity) string {
+	switch {
+	case severity >= linter.CRITICAL:
+		return "error"
+	case severity >= linter.MINOR:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// WriteSARIF writes the violations in goPackages to w as a SARIF 2.1.0 log, with the
+// file locations relative to rootDir. Diagnostics are tool execution notifications.
+func WriteSARIF(w io.Writer, goPackages []*linter.GoPackage, rootDir string) error {
+	absRootDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return err
+	}
+
+	run := &sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           globalvars.PROGRAM_NAME,
+			Version:        globalvars.VERSION,
+			InformationUri: globalvars.WEBSITE,
+		}},
+		OriginalUriBaseIds: map[string]*sarifArtifactLocation{
+			SARIF_SRCROOT: {Uri: fileUri(absRootDir) + "/"},
+		},
+		ColumnKind: SARIF_COLUMN_KIND,
+		Results:    []*sarifResult{},
+	}
+
+	ruleIndex := make(map[linter.Rule]int)
+	for _, checker := range linter.Checkers() {
+		info := checker.Info()
+		ruleIndex[info.Rule] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, &sarifReportingDescriptor{
+			Id:                   info.Rule.String(),
+			Name:                 info.Name,
+			ShortDescription:     sarifMessage{Text: info.Name},
+			FullDescription:      sarifMessage{Text: info.Description},
+			HelpUri:              globalvars.WIKI_PAGE,
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(info.Severity)},
+			Properties:           sarifRuleProperties{Severity: info.Severity.String(), Tags: info.Tags},
+		})
+	}
+
+	for _, goFile := range GroupByFile(goPackages) {
+		artifactLocation := artifactLocation(absRootDir, goFile.FilePath)
+		src, _ := ioutil.ReadFile(goFile.FilePath) // Columns stay in bytes if the file can not be read.
+		for _, violation := range goFile.Violations {
+			index, ok := ruleIndex[violation.Type]
+			if !ok {
+				index = -1 // Rule is not registered, SARIF uses -1 for unknown rule index.
+			}
+			run.Results = append(run.Results, &sarifResult{
+				RuleId:    violation.Type.String(),
+				RuleIndex: index,
+				Level:     sarifLevel(violation.Severity),
+				Message:   sarifMessage{Text: violation.Description},
+				Locations: []*sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: artifactLocation,
+						Region: sarifRegion{
+							StartLine:   violation.StartLine,
+							StartColumn: codePointColumn(src, violation.StartOffset, violation.StartColumn),
+							EndLine:     violation.EndLine,
+							EndColumn:   codePointColumn(src, violation.EndOffset, violation.EndColumn),
+							ByteOffset:  violation.StartOffset,
+							ByteLength:  violation.EndOffset - violation.StartOffset,
+						},
+					},
+				}},
+				Fixes:      sarifFixes(artifactLocation, violation),
+				Properties: sarifProperties(violation),
+			})
+		}
+	}
+
+	run.Invocations = []*sarifInvocation{sarifDiagnostics(goPackages, absRootDir)}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&sarifLog{Schema: SARIF_SCHEMA, Version: SARIF_VERSION, Runs: []*sarifRun{run}})
+}
+
+// sarifDiagnostics returns the invocation of the analysis, reporting the diagnostics in goPackages
+// as notifications. The execution is unsuccessful if a package could not be fully type checked.
+func sarifDiagnostics(goPackages []*linter.GoPackage, absRootDir string) *sarifInvocation {
+	invocation := &sarifInvocation{ExecutionSuccessful: true}
+	for _, goFile := range GroupFindingsByFile(goPackages) {
+		for _, diagnostic := range goFile.Diagnostics {
+			notification := &sarifNotification{
+				Descriptor: sarifDescriptorReference{Id: string(diagnostic.Kind)},
+				Level:      "error",
+				Message:    sarifMessage{Text: diagnostic.Message},
+			}
+			if diagnostic.FilePath != "" {
+				location := &sarifNotificationLocation{PhysicalLocation: sarifNotificationPhysicalLocation{
+					ArtifactLocation: artifactLocation(absRootDir, diagnostic.FilePath),
+				}}
+				if diagnostic.Line > 0 {
+					column := diagnostic.Column
+					src, _ := ioutil.ReadFile(diagnostic.FilePath)
+					if lineStart := lineOffset(src, diagnostic.Line); lineStart >= 0 {
+						column = codePointColumn(src, lineStart+diagnostic.Column-1, diagnostic.Column)
+					}
+					location.PhysicalLocation.Region = &sarifLineRegion{StartLine: diagnostic.Line, StartColumn: column}
+				}
+				notification.Locations = []*sarifNotificationLocation{location}
+			}
+			if len(diagnostic.Builds) > 0 {
+				notification.Properties = &sarifResultProperties{Builds: diagnostic.Builds}
+			}
+			invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, notification)
+			if diagnostic.Incomplete() || diagnostic.Failed() {
+				invocation.ExecutionSuccessful = false
+			}
+		}
+	}
+	return invocation
+}
+
+// codePointColumn returns the 1-based column of the byte offset in src counted in Unicode code points,
+// or byteColumn if it is unknown or src does not hold the offset.
+func codePointColumn(src []byte, offset int, byteColumn int) int {
+	if byteColumn <= 0 || offset < 0 || offset > len(src) {
+		return byteColumn
+	}
+	lineStart := bytes.LastIndexByte(src[:offset], '\n') + 1
+	return utf8.RuneCount(src[lineStart:offset]) + 1
+}
+
+// lineOffset returns the byte offset in src where the 1-based line starts, -1 if there is no such line.
+func lineOffset(src []byte, line int) int {
+	offset := 0
+	for ; line > 1; line-- {
+		newline := bytes.IndexByte(src[offset:], '\n')
+		if newline < 0 {
+			return -1
+		}
+		offset += newline + 1
+	}
+	return offset
+}
+
+// sarifProperties returns the properties of the result of the violation, nil if there are none.
+func sarifProperties(violation *linter.Violation) *sarifResultProperties {
+	if len(violation.Builds) == 0 {
+		return nil
+	}
+	return &sarifResultProperties{Builds: violation.Builds}
+}
+
+// sarifFixes returns the suggested fixes of the violation in the file at artifactLocation.
+func sarifFixes(artifactLocation sarifArtifactLocation, violation *linter.Violation) []*sarifFix {
+	var fixes []*sarifFix
+	for _, suggestedFix := range violation.SuggestedFixes {
+		artifactChange := &sarifArtifactChange{ArtifactLocation: artifactLocation}
+		for _, textEdit := range suggestedFix.TextEdits {
+			replacement := &sarifReplacement{DeletedRegion: sarifByteRegion{
+				ByteOffset: textEdit.StartOffset,
+				ByteLength: textEdit.EndOffset - textEdit.StartOffset,
+			}}
+			if len(textEdit.NewText) > 0 {
+				replacement.InsertedContent = &sarifArtifactContent{Text: textEdit.NewText}
+			}
+			artifactChange.Replacements = append(artifactChange.Replacements, replacement)
+		}
+		fixes = append(fixes, &sarifFix{
+			Description:     sarifMessage{Text: suggestedFix.Message},
+			ArtifactChanges: []*sarifArtifactChange{artifactChange},
+		})
+	}
+	return fixes
+}
+
+// artifactLocation returns the location of filePath relative to the SRCROOT base id,
+// or the absolute file URI if filePath is outside absRootDir.
+func artifactLocation(absRootDir string, filePath string) sarifArtifactLocation {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		absPath = filePath
+	}
+	if relPath, err := filepath.Rel(absRootDir, absPath); err == nil && !strings.HasPrefix(relPath, "..") {
+		return sarifArtifactLocation{Uri: (&url.URL{Path: filepath.ToSlash(relPath)}).String(), UriBaseId: SARIF_SRCROOT}
+	}
+	return sarifArtifactLocation{Uri: fileUri(absPath)}
+}
+
+// fileUri returns the file URI of the absolute path.
+func fileUri(absPath string) string {
+	slashPath := filepath.ToSlash(absPath)
+	if !strings.HasPrefix(slashPath, "/") {
+		slashPath = "/" + slashPath // Windows drive letter.
+	}
+	return (&url.URL{Scheme: "file", Path: slashPath}).String()
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2015-2016 The GoAnalysis Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style license that can
+// be found in the LICENSE file.
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrisbbe/GoAnalysis/analyzer/globalvars"
+	"github.com/chrisbbe/GoAnalysis/analyzer/linter"
+	"github.com/chrisbbe/GoAnalysis/analyzer/report"
+)
+
+// detectViolations runs the linter on the linter test code in dir.
+func detectViolations(t *testing.T, dir string) []*linter.GoPackage {
+	goPackages, err := linter.DetectViolations(linter.NewConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return goPackages
+}
+
+// brokenPackages runs the linter on a package that does not type check, returning the
+// packages with violations or diagnostics.
+func brokenPackages(t *testing.T) []*linter.GoPackage {
+	return sourcePackages(t, "package main\n\nfunc main() {\n\tundefinedFunc()\n}\n")
+}
+
+// sourcePackages runs the linter on src written to main.go in a temporary module, returning the
+// packages with violations or diagnostics.
+func sourcePackages(t *testing.T, src string) []*linter.GoPackage {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/broken\n\ngo 1.25\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	goPackages, err := linter.AnalyzePackages(linter.NewConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return linter.WithFindings(goPackages)
+}
+
+func TestWriteSARIF(t *testing.T) {
+	rootDir := "../linter/testcode/emptyelsebody"
+	var buffer bytes.Buffer
+	if err := report.WriteSARIF(&buffer, detectViolations(t, rootDir), rootDir); err != nil {
+		t.Fatal(err)
+	}
+
+	var sarifLog struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name    string
+					Version string
+					Rules   []struct{ Id string }
+				}
+			}
+			Results []struct {
+				RuleId    string
+				RuleIndex int
+				Level     string
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ Uri, UriBaseId string }
+						Region           struct{ StartLine, StartColumn, EndLine, EndColumn, ByteLength int }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &sarifLog); err != nil {
+		t.Fatal(err)
+	}
+
+	if sarifLog.Version != "2.1.0" || len(sarifLog.Runs) != 1 {
+		t.Fatalf("SARIF log should be version 2.1.0 with one run, not version %s with %d runs!", sarifLog.Version, len(sarifLog.Runs))
+	}
+	run := sarifLog.Runs[0]
+	if run.Tool.Driver.Name != globalvars.PROGRAM_NAME || run.Tool.Driver.Version != globalvars.VERSION {
+		t.Errorf("Tool should be %s %s, and not %s %s!", globalvars.PROGRAM_NAME, globalvars.VERSION,
+			run.Tool.Driver.Name, run.Tool.Driver.Version)
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("Number of results should be 1, but are %d!", len(run.Results))
+	}
+
+	result := run.Results[0]
+	if result.RuleId != linter.EMPTY_ELSE_BODY.String() || run.Tool.Driver.Rules[result.RuleIndex].Id != result.RuleId {
+		t.Errorf("Result should refer to rule %s, and not %s!", linter.EMPTY_ELSE_BODY, result.RuleId)
+	}
+	if result.Level != "warning" {
+		t.Errorf("Result level should be warning, and not %s!", result.Level)
+	}
+	location := result.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.Uri != "main.go" || location.ArtifactLocation.UriBaseId != report.SARIF_SRCROOT {
+		t.Errorf("Result should be located in %s main.go, and not %s %s!", report.SARIF_SRCROOT,
+			location.ArtifactLocation.UriBaseId, location.ArtifactLocation.Uri)
+	}
+	if region := location.Region; region.StartLine != 16 || region.StartColumn != 9 || region.EndLine != 18 ||
+		region.EndColumn != 3 || region.ByteLength != 5 {
+		t.Errorf("Result should be located at 16:9-18:3 with length 5, and not %d:%d-%d:%d with length %d!",
+			region.StartLine, region.StartColumn, region.EndLine, region.EndColumn, region.ByteLength)
+	}
+}
+
+func TestWriteSARIFWithDiagnostics(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := report.WriteSARIF(&buffer, brokenPackages(t), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var sarifLog struct {
+		Runs []struct {
+			Invocations []struct {
+				ExecutionSuccessful        bool
+				ToolExecutionNotifications []struct {
+					Descriptor struct{ Id string }
+					Level      string
+					Locations  []struct {
+						PhysicalLocation struct {
+							Region struct{ StartLine, StartColumn int }
+						}
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &sarifLog); err != nil {
+		t.Fatal(err)
+	}
+
+	invocation := sarifLog.Runs[0].Invocations[0]
+	if invocation.ExecutionSuccessful {
+		t.Error("Execution should not be successful when a package does not type check!")
+	}
+	if len(invocation.ToolExecutionNotifications) != 1 {
+		t.Fatalf("Number of notifications should be 1, but are %d!", len(invocation.ToolExecutionNotifications))
+	}
+	notification := invocation.ToolExecutionNotifications[0]
+	if notification.Descriptor.Id != string(linter.TYPE_ERROR) || notification.Level != "error" {
+		t.Errorf("Notification should be a %s error, and not a %s %s!", linter.TYPE_ERROR, notification.Descriptor.Id, notification.Level)
+	}
+	if region := notification.Locations[0].PhysicalLocation.Region; region.StartLine != 4 || region.StartColumn != 2 {
+		t.Errorf("Notification should be located at 4:2, and not %d:%d!", region.StartLine, region.StartColumn)
+	}
+}
+
+// Columns count Unicode code points, as the run declares with its column kind.
+func TestWriteSARIFColumnKind(t *testing.T) {
+	src := "package main\n\nfunc main() {\n\tname := \"Åse\"; if name == \"Ærlig\" {\n\t}\n\t_ = \"π\"; undefinedFunc()\n}\n"
+	var buffer bytes.Buffer
+	if err := report.WriteSARIF(&buffer, sourcePackages(t, src), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	type region struct{ StartLine, StartColumn, EndLine, EndColumn int }
+	var sarifLog struct {
+		Runs []struct {
+			ColumnKind string
+			Results    []struct {
+				Locations []struct {
+					PhysicalLocation struct{ Region region }
+				}
+			}
+			Invocations []struct {
+				ToolExecutionNotifications []struct {
+					Locations []struct {
+						PhysicalLocation struct{ Region region }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &sarifLog); err != nil {
+		t.Fatal(err)
+	}
+
+	run := sarifLog.Runs[0]
+	if run.ColumnKind != report.SARIF_COLUMN_KIND {
+		t.Errorf("Column kind should be %s, and not %q!", report.SARIF_COLUMN_KIND, run.ColumnKind)
+	}
+	if len(run.Results) != 1 || len(run.Invocations[0].ToolExecutionNotifications) != 1 {
+		t.Fatalf("There should be one result and one notification, and not %d and %d!",
+			len(run.Results), len(run.Invocations[0].ToolExecutionNotifications))
+	}
+	if actual := run.Results[0].Locations[0].PhysicalLocation.Region; actual != (region{4, 17, 5, 3}) { // Byte column 18.
+		t.Errorf("Result should be located at 4:17 to 5:3, and not %d:%d to %d:%d!",
+			actual.StartLine, actual.StartColumn, actual.EndLine, actual.EndColumn)
+	}
+	if actual := run.Invocations[0].ToolExecutionNotifications[0].Locations[0].PhysicalLocation.Region; actual.StartLine != 6 ||
+		actual.StartColumn != 11 { // Byte column 12.
+		t.Errorf("Notification should be located at 6:11, and not %d:%d!", actual.StartLine, actual.StartColumn)
+	}
+}